@@ -0,0 +1,110 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io/ioutil"
+
+	"github.com/onosproject/gnmi-netconf-adapter/pkg/adapter/creds"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// credentialsConfig is the YAML file format accepted by --credentials-config, selecting which
+// creds.Provider dials NETCONF sessions. Exactly one of its sections should be populated, matching
+// Type.
+type credentialsConfig struct {
+	// Type selects the provider: "static" (the default when no file is given), "keyfile", "exec",
+	// "file", or "url".
+	Type string `yaml:"type"`
+
+	Static  *staticCredentialsConfig  `yaml:"static"`
+	KeyFile *keyFileCredentialsConfig `yaml:"keyfile"`
+	Exec    *execCredentialsConfig    `yaml:"exec"`
+	File    *fileCredentialsConfig    `yaml:"file"`
+	URL     *urlCredentialsConfig     `yaml:"url"`
+}
+
+type staticCredentialsConfig struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+type keyFileCredentialsConfig struct {
+	Username       string `yaml:"username"`
+	KeyPath        string `yaml:"key_path"`
+	KnownHostsPath string `yaml:"known_hosts_path"`
+}
+
+type execCredentialsConfig struct {
+	Command string   `yaml:"command"`
+	Args    []string `yaml:"args"`
+}
+
+type fileCredentialsConfig struct {
+	Path string `yaml:"path"`
+}
+
+type urlCredentialsConfig struct {
+	URL         string `yaml:"url"`
+	TokenEnvVar string `yaml:"token_env_var"`
+}
+
+// loadCredentialProvider reads the credentials config file at path and builds the creds.Provider it
+// describes.
+func loadCredentialProvider(path string) (creds.Provider, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read credentials config %s", path)
+	}
+	var cfg credentialsConfig
+	if err := yaml.Unmarshal(raw, &cfg); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse credentials config %s", path)
+	}
+	return newCredentialProvider(cfg)
+}
+
+// newCredentialProvider builds the creds.Provider described by cfg.
+func newCredentialProvider(cfg credentialsConfig) (creds.Provider, error) {
+	switch cfg.Type {
+	case "", "static":
+		if cfg.Static == nil {
+			return nil, errors.New("credentials config type \"static\" requires a static section")
+		}
+		return creds.NewStaticProvider(cfg.Static.Username, cfg.Static.Password), nil
+	case "keyfile":
+		if cfg.KeyFile == nil {
+			return nil, errors.New("credentials config type \"keyfile\" requires a keyfile section")
+		}
+		return creds.NewKeyFileProvider(cfg.KeyFile.Username, cfg.KeyFile.KeyPath, cfg.KeyFile.KnownHostsPath)
+	case "exec":
+		if cfg.Exec == nil {
+			return nil, errors.New("credentials config type \"exec\" requires an exec section")
+		}
+		return creds.NewExecProvider(cfg.Exec.Command, cfg.Exec.Args...), nil
+	case "file":
+		if cfg.File == nil {
+			return nil, errors.New("credentials config type \"file\" requires a file section")
+		}
+		return creds.NewFileProvider(cfg.File.Path)
+	case "url":
+		if cfg.URL == nil {
+			return nil, errors.New("credentials config type \"url\" requires a url section")
+		}
+		return creds.NewURLProvider(cfg.URL.URL, cfg.URL.TokenEnvVar), nil
+	default:
+		return nil, errors.Errorf("unknown credentials config type %q", cfg.Type)
+	}
+}
@@ -17,13 +17,12 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"strings"
 
 	"github.com/damianoneill/net/v2/netconf/ops"
 	adapter "github.com/onosproject/gnmi-netconf-adapter/pkg/adapter"
+	"github.com/onosproject/gnmi-netconf-adapter/pkg/adapter/creds"
 	"github.com/onosproject/gnmi-netconf-adapter/pkg/adapter/modeldata"
 	"github.com/onosproject/gnmi-netconf-adapter/pkg/adapter/modeldata/gostruct"
-	"golang.org/x/crypto/ssh"
 
 	pb "github.com/openconfig/gnmi/proto/gnmi"
 )
@@ -32,23 +31,58 @@ var (
 	model = adapter.NewModel(modeldata.ModelData, gostruct.SchemaTree["Device"])
 )
 
-// newGnmiServer creates a new gNMI server for a model and a device instance
-func newGnmiServer(model *adapter.Model, ipAddress, username, password string) (pb.GNMIServer, error) {
-	s, err := ncDeviceSessionForDemo(ipAddress, username, password)
+// newGnmiServer creates a new gNMI server for a model and a device instance. When yangDir is
+// non-empty, the compiled-in model is ignored in favour of a ModelRegistry loaded from yangDir,
+// with the Model selected from the device's advertised NETCONF capabilities. provider supplies the
+// credentials the NETCONF session is dialed with.
+func newGnmiServer(model *adapter.Model, ipAddress string, provider creds.Provider, yangDir string) (pb.GNMIServer, error) {
+	s, err := creds.NewSessionWithCredentials(context.Background(), provider, ipAddress)
 	if err != nil {
 		return nil, err
 	}
-	return adapter.NewAdapter(model, s)
+
+	if yangDir == "" {
+		return adapter.NewAdapter(model, s)
+	}
+
+	registry := adapter.NewModelRegistry()
+	if err := registry.LoadDir(yangDir); err != nil {
+		return nil, err
+	}
+
+	capabilitiesSession, ok := s.(interface{ ServerCapabilities() []string })
+	if !ok {
+		return nil, fmt.Errorf("NETCONF session for %s does not expose its hello capabilities", ipAddress)
+	}
+	return adapter.NewAdapterFromRegistry(registry, capabilitiesSession.ServerCapabilities(), s)
 }
 
-func ncDeviceSessionForDemo(ipAddress, username, password string) (ops.OpSession, error) {
-	sshConfig := &ssh.ClientConfig{
-		User:            username,
-		Auth:            []ssh.AuthMethod{ssh.Password(password)},
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+// newGnmiDeviceRegistryServer creates a gNMI server that fronts the set of NETCONF devices described
+// by the inventory file at path, routed per RPC by gNMI target (see adapter.DeviceRegistry).
+func newGnmiDeviceRegistryServer(model *adapter.Model, path string) (pb.GNMIServer, error) {
+	registry := adapter.NewDeviceRegistry(func(ctx context.Context, cfg adapter.DeviceConfig) (ops.OpSession, error) {
+		return creds.NewSessionWithCredentials(ctx, creds.NewStaticProvider(cfg.Username, cfg.Password), cfg.Address)
+	}, 0)
+	if err := registry.LoadInventoryFile(path); err != nil {
+		return nil, err
 	}
-	if !strings.Contains(ipAddress, ":") {
-		ipAddress = fmt.Sprintf("%s:%d", ipAddress, 830)
+	return adapter.NewAdapterWithDeviceRegistry(model, registry)
+}
+
+// newGnmiTargetResolverServer creates a gNMI server that fronts the set of NETCONF devices described
+// by the inventory file at path, routed per RPC by gNMI target, with each target's schema selected by
+// name from the YANG vendor packs in yangDir (see adapter.StaticTargetResolver).
+func newGnmiTargetResolverServer(yangDir, path string) (pb.GNMIServer, error) {
+	registry := adapter.NewModelRegistry()
+	if err := registry.LoadDir(yangDir); err != nil {
+		return nil, err
+	}
+
+	resolver := adapter.NewStaticTargetResolver(registry, func(ctx context.Context, cfg adapter.TargetConfig) (ops.OpSession, error) {
+		return creds.NewSessionWithCredentials(ctx, creds.NewStaticProvider(cfg.Username, cfg.Password), cfg.Address)
+	}, 0)
+	if err := resolver.LoadInventoryFile(path); err != nil {
+		return nil, err
 	}
-	return ops.NewSession(context.Background(), sshConfig, ipAddress)
+	return adapter.NewAdapterWithTargetResolver(resolver)
 }
@@ -0,0 +1,52 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io/ioutil"
+
+	"github.com/onosproject/gnmi-netconf-adapter/pkg/adapter/oidcauth"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// oidcGuardConfig is the YAML file format accepted by --oidc-config, configuring one or more OIDC
+// issuers to authenticate bearer tokens against and the group policy to authorize them with.
+type oidcGuardConfig struct {
+	Issuers    []oidcauth.IssuerConfig `yaml:"issuers"`
+	PolicyFile string                  `yaml:"policy_file"`
+}
+
+// loadOIDCGuard reads the OIDC config file at path and builds the oidcauth.Guard it describes.
+func loadOIDCGuard(path string) (*oidcauth.Guard, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read OIDC config %s", path)
+	}
+	var cfg oidcGuardConfig
+	if err := yaml.Unmarshal(raw, &cfg); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse OIDC config %s", path)
+	}
+
+	authenticator, err := oidcauth.NewAuthenticator(cfg.Issuers)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := oidcauth.LoadGroupPolicy(cfg.PolicyFile)
+	if err != nil {
+		return nil, err
+	}
+	return oidcauth.NewGuard(authenticator, policy), nil
+}
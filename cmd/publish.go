@@ -0,0 +1,359 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/onosproject/gnmi-netconf-adapter/pkg/adapter"
+	"github.com/onosproject/gnmi-netconf-adapter/pkg/adapter/creds"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/ygot/ygot"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"gopkg.in/yaml.v2"
+	log "k8s.io/klog"
+)
+
+var (
+	dialOutDeviceIP       *string
+	dialOutDeviceUsername *string
+	dialOutDevicePassword *string
+
+	dialOutSubscriptionFile *string
+)
+
+// dialOutCmd represents the dial-out command.
+var dialOutCmd = &cobra.Command{
+	Use:   "dial-out",
+	Short: "Runs the adapter as a gNMI dial-out telemetry publisher",
+	Run:   RunDialOut,
+}
+
+func init() {
+	dialOutDeviceIP = dialOutCmd.Flags().String("device-ip", "10.228.63.5:830", "device ip address:port for NETCONF")
+	dialOutDeviceUsername = dialOutCmd.Flags().String("device-user", "", "device NETCONF username")
+	dialOutDevicePassword = dialOutCmd.Flags().String("device-pass", "", "device NETCONF password")
+
+	dialOutSubscriptionFile = dialOutCmd.Flags().String("subscription-file", "", "YAML file describing the paths to publish and the collectors to stream them to")
+
+	rootCmd.AddCommand(dialOutCmd)
+}
+
+// RunDialOut provides an indirection so that the logic can be tested independently of the cobra infrastructure.
+func RunDialOut(command *cobra.Command, args []string) {
+	log.Info("Run GNMI dial-out publisher... ")
+	err := Publish(func(startedMsg string) {
+		log.Info(startedMsg)
+	})
+	log.Exitf("Publish gave error=%v", err)
+}
+
+// dialOutConfig is the YAML subscription file format: the paths to publish and the collectors to
+// stream them to.
+type dialOutConfig struct {
+	Paths      []dialOutPathConfig      `yaml:"paths"`
+	Collectors []dialOutCollectorConfig `yaml:"collectors"`
+}
+
+// dialOutPathConfig configures telemetry publication for a single gNMI path, mirroring the fields
+// of a dial-in gnmi.Subscription.
+type dialOutPathConfig struct {
+	Path string `yaml:"path"`
+	// Mode is "on_change" (the default) to bridge the path off the device's NETCONF
+	// <create-subscription>/RFC 8641 YANG-Push notification stream, or "sample" to poll it on
+	// SampleIntervalSeconds instead.
+	Mode                     string `yaml:"mode"`
+	SampleIntervalSeconds    int    `yaml:"sample_interval_seconds"`
+	HeartbeatIntervalSeconds int    `yaml:"heartbeat_interval_seconds"`
+	SuppressRedundant        bool   `yaml:"suppress_redundant"`
+}
+
+// dialOutCollectorConfig configures one gNMI dial-out collector to stream to, including the TLS
+// material used to dial it. Collectors are configured independently so that each can sit in its own
+// trust domain rather than sharing one adapter-wide CA/certificate/server name.
+type dialOutCollectorConfig struct {
+	Address    string `yaml:"address"`
+	Insecure   bool   `yaml:"insecure"`
+	CAFile     string `yaml:"ca_file"`
+	CertFile   string `yaml:"cert_file"`
+	KeyFile    string `yaml:"key_file"`
+	ServerName string `yaml:"server_name"`
+}
+
+// collectorQueueDepth bounds the number of buffered updates per collector before the oldest queued
+// sample is dropped in favour of the newest, mirroring the adapter's dial-in subscriptionManager.
+const collectorQueueDepth = 100
+
+// Publish runs the adapter as a gNMI dial-out client: it opens a NETCONF session to the configured
+// device, then drives the subscription file's paths through the adapter's own Subscribe RPC as a
+// single STREAM subscription - so an "on_change" path is backed by the same NETCONF
+// <create-subscription>/RFC 8641 YANG-Push notification bridge (see subscriptionManager) a dial-in
+// Subscribe STREAM client gets, rather than a separate polling loop - and fans out the resulting
+// gnmi.SubscribeResponse notifications to every configured collector.
+func Publish(started func(string)) error {
+	if *dialOutSubscriptionFile == "" {
+		return fmt.Errorf("--subscription-file is required")
+	}
+
+	cfgBytes, err := ioutil.ReadFile(*dialOutSubscriptionFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read subscription file %s", *dialOutSubscriptionFile)
+	}
+	var cfg dialOutConfig
+	if err := yaml.Unmarshal(cfgBytes, &cfg); err != nil {
+		return errors.Wrap(err, "failed to parse subscription file")
+	}
+	if len(cfg.Collectors) == 0 {
+		return fmt.Errorf("subscription file must configure at least one collector")
+	}
+
+	sub, err := buildSubscriptionList(cfg.Paths)
+	if err != nil {
+		return err
+	}
+
+	ncs, err := creds.NewSessionWithCredentials(context.Background(), creds.NewStaticProvider(*dialOutDeviceUsername, *dialOutDevicePassword), *dialOutDeviceIP)
+	if err != nil {
+		return err
+	}
+	a, err := adapter.NewAdapter(model, ncs)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	collectorQueues := make([]chan *pb.SubscribeResponse, len(cfg.Collectors))
+	for i, c := range cfg.Collectors {
+		collectorQueues[i] = make(chan *pb.SubscribeResponse, collectorQueueDepth)
+		go streamToCollector(ctx, c, collectorQueues[i])
+	}
+
+	stream := newDialOutSubscribeStream(ctx, sub)
+	go func() {
+		if err := a.Subscribe(stream); err != nil {
+			log.Errorf("dial-out subscription to %s ended: %v", *dialOutDeviceIP, err)
+		}
+	}()
+
+	started(fmt.Sprintf("publishing %d path(s) from %s to %d collector(s)", len(sub.GetSubscription()), *dialOutDeviceIP, len(cfg.Collectors)))
+
+	for resp := range stream.responses {
+		broadcast(collectorQueues, resp)
+	}
+	return nil
+}
+
+// buildSubscriptionList converts the subscription file's path configs into the STREAM
+// gnmi.SubscriptionList the adapter's Subscribe RPC expects, one gnmi.Subscription per path.
+// UpdatesOnly is set so the stream skips the initial present-config walk a dial-in client's first
+// sync would get: a dial-out publisher only ever has collectors downstream of it, and they should
+// see NETCONF-originated changes, not a replay of the config as of subscribe time.
+func buildSubscriptionList(paths []dialOutPathConfig) (*pb.SubscriptionList, error) {
+	subs := make([]*pb.Subscription, len(paths))
+	for i, p := range paths {
+		path, err := ygot.StringToStructuredPath(p.Path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid path %q in subscription file", p.Path)
+		}
+
+		mode := pb.SubscriptionMode_ON_CHANGE
+		if p.Mode == "sample" {
+			mode = pb.SubscriptionMode_SAMPLE
+		}
+		interval := time.Duration(p.SampleIntervalSeconds) * time.Second
+		heartbeat := time.Duration(p.HeartbeatIntervalSeconds) * time.Second
+
+		subs[i] = &pb.Subscription{
+			Path:              path,
+			Mode:              mode,
+			SampleInterval:    uint64(interval.Nanoseconds()),
+			HeartbeatInterval: uint64(heartbeat.Nanoseconds()),
+			SuppressRedundant: p.SuppressRedundant,
+		}
+	}
+	return &pb.SubscriptionList{Mode: pb.SubscriptionList_STREAM, Subscription: subs, UpdatesOnly: true}, nil
+}
+
+// dialOutSubscribeStream adapts a gnmi.SubscriptionList to the gnmi.GNMI_SubscribeServer interface
+// the adapter's Subscribe RPC is written against, letting dial-out drive the exact same STREAM
+// subscription machinery - ON_CHANGE NETCONF notification bridging, SAMPLE tickers,
+// suppress-redundant/heartbeat - that a dial-in client's Subscribe RPC uses. Recv yields sub once
+// and then blocks until ctx is done, since the adapter only ever sends on this stream.
+type dialOutSubscribeStream struct {
+	ctx       context.Context
+	sub       *pb.SubscriptionList
+	sent      bool
+	responses chan *pb.SubscribeResponse
+}
+
+func newDialOutSubscribeStream(ctx context.Context, sub *pb.SubscriptionList) *dialOutSubscribeStream {
+	return &dialOutSubscribeStream{ctx: ctx, sub: sub, responses: make(chan *pb.SubscribeResponse, collectorQueueDepth)}
+}
+
+func (s *dialOutSubscribeStream) Send(m *pb.SubscribeResponse) error {
+	select {
+	case s.responses <- m:
+		return nil
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	}
+}
+
+func (s *dialOutSubscribeStream) Recv() (*pb.SubscribeRequest, error) {
+	if s.sent {
+		<-s.ctx.Done()
+		return nil, s.ctx.Err()
+	}
+	s.sent = true
+	return &pb.SubscribeRequest{Request: &pb.SubscribeRequest_Subscribe{Subscribe: s.sub}}, nil
+}
+
+func (s *dialOutSubscribeStream) Context() context.Context { return s.ctx }
+
+func (s *dialOutSubscribeStream) SetHeader(metadata.MD) error  { return nil }
+func (s *dialOutSubscribeStream) SendHeader(metadata.MD) error { return nil }
+func (s *dialOutSubscribeStream) SetTrailer(metadata.MD)       {}
+
+func (s *dialOutSubscribeStream) SendMsg(m interface{}) error {
+	return s.Send(m.(*pb.SubscribeResponse))
+}
+
+func (s *dialOutSubscribeStream) RecvMsg(m interface{}) error {
+	req, err := s.Recv()
+	if err != nil {
+		return err
+	}
+	*m.(*pb.SubscribeRequest) = *req
+	return nil
+}
+
+// broadcast enqueues resp on every collector queue, dropping the oldest queued update on a queue
+// rather than blocking the whole publisher when one collector falls behind.
+func broadcast(collectorQueues []chan *pb.SubscribeResponse, resp *pb.SubscribeResponse) {
+	for _, q := range collectorQueues {
+		select {
+		case q <- resp:
+		default:
+			select {
+			case <-q:
+			default:
+			}
+			select {
+			case q <- resp:
+			default:
+			}
+		}
+	}
+}
+
+// dialOutMethod is the streaming RPC used by gNMI dial-out collectors (as implemented by, e.g.,
+// SONiC's dialout_client_cli): a bidirectional stream of SubscribeResponse messages, with no
+// generated client stub required since the adapter only ever sends on it.
+const dialOutMethod = "/gnmi.gNMIDialOut/Publish"
+
+var dialOutStreamDesc = &grpc.StreamDesc{
+	StreamName:    "Publish",
+	ClientStreams: true,
+	ServerStreams: true,
+}
+
+// defaultDialOutBackoff and maxDialOutBackoff bound the delay streamToCollector waits between
+// reconnect attempts to a collector.
+const (
+	defaultDialOutBackoff = time.Second
+	maxDialOutBackoff     = 30 * time.Second
+)
+
+// streamToCollector dials c and forwards every update enqueued for it, reconnecting with
+// exponential backoff whenever the stream or the underlying connection fails.
+func streamToCollector(ctx context.Context, c dialOutCollectorConfig, updates <-chan *pb.SubscribeResponse) {
+	backoff := defaultDialOutBackoff
+
+	for {
+		if err := runCollectorStream(ctx, c, updates); err != nil {
+			log.Warningf("dial-out stream to collector %s failed, retrying in %s: %v", c.Address, backoff, err)
+			time.Sleep(backoff)
+			backoff = nextBackoff(backoff)
+			continue
+		}
+		return
+	}
+}
+
+// nextBackoff doubles d, capping the result at maxDialOutBackoff.
+func nextBackoff(d time.Duration) time.Duration {
+	d *= 2
+	if d > maxDialOutBackoff {
+		return maxDialOutBackoff
+	}
+	return d
+}
+
+// runCollectorStream opens one dial-out stream to c and drains updates onto it until either the
+// stream fails or updates is closed.
+func runCollectorStream(ctx context.Context, c dialOutCollectorConfig, updates <-chan *pb.SubscribeResponse) error {
+	opts, err := collectorDialOptions(c)
+	if err != nil {
+		return err
+	}
+
+	conn, err := grpc.DialContext(ctx, c.Address, opts...)
+	if err != nil {
+		return errors.Wrapf(err, "failed to dial collector %s", c.Address)
+	}
+	defer conn.Close()
+
+	stream, err := conn.NewStream(ctx, dialOutStreamDesc, dialOutMethod)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open dial-out stream to %s", c.Address)
+	}
+
+	for resp := range updates {
+		if err := stream.SendMsg(resp); err != nil {
+			return errors.Wrapf(err, "failed to send update to %s", c.Address)
+		}
+	}
+	return stream.CloseSend()
+}
+
+// collectorDialOptions builds the grpc.DialOption(s) used to dial c, using its own CA/client
+// certificate/server name override rather than one global TLS config, so collectors that sit in
+// different trust domains can each be reached correctly.
+func collectorDialOptions(c dialOutCollectorConfig) ([]grpc.DialOption, error) {
+	if c.Insecure {
+		return []grpc.DialOption{grpc.WithInsecure()}, nil
+	}
+
+	tlsCfg := &tls.Config{ServerName: c.ServerName}
+	if c.CAFile != "" {
+		tlsCfg.RootCAs = getCertPool(c.CAFile)
+	}
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load client certificate for collector %s", c.Address)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	return []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(tlsCfg))}, nil
+}
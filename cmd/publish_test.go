@@ -0,0 +1,202 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+func TestBuildSubscriptionListDefaultsToOnChange(t *testing.T) {
+	sub, err := buildSubscriptionList([]dialOutPathConfig{{Path: "/configuration/version"}})
+	if err != nil {
+		t.Fatalf("buildSubscriptionList() error = %v", err)
+	}
+	if got := sub.GetSubscription()[0].GetMode(); got != pb.SubscriptionMode_ON_CHANGE {
+		t.Errorf("Mode = %v, want ON_CHANGE", got)
+	}
+	if !sub.GetUpdatesOnly() {
+		t.Errorf("UpdatesOnly = false, want true")
+	}
+	if sub.GetMode() != pb.SubscriptionList_STREAM {
+		t.Errorf("SubscriptionList.Mode = %v, want STREAM", sub.GetMode())
+	}
+}
+
+func TestBuildSubscriptionListSampleMode(t *testing.T) {
+	sub, err := buildSubscriptionList([]dialOutPathConfig{{
+		Path:                     "/configuration/version",
+		Mode:                     "sample",
+		SampleIntervalSeconds:    5,
+		HeartbeatIntervalSeconds: 30,
+		SuppressRedundant:        true,
+	}})
+	if err != nil {
+		t.Fatalf("buildSubscriptionList() error = %v", err)
+	}
+	got := sub.GetSubscription()[0]
+	if got.GetMode() != pb.SubscriptionMode_SAMPLE {
+		t.Errorf("Mode = %v, want SAMPLE", got.GetMode())
+	}
+	if want := uint64((5 * time.Second).Nanoseconds()); got.GetSampleInterval() != want {
+		t.Errorf("SampleInterval = %d, want %d", got.GetSampleInterval(), want)
+	}
+	if want := uint64((30 * time.Second).Nanoseconds()); got.GetHeartbeatInterval() != want {
+		t.Errorf("HeartbeatInterval = %d, want %d", got.GetHeartbeatInterval(), want)
+	}
+	if !got.GetSuppressRedundant() {
+		t.Errorf("SuppressRedundant = false, want true")
+	}
+}
+
+func TestBuildSubscriptionListInvalidPath(t *testing.T) {
+	if _, err := buildSubscriptionList([]dialOutPathConfig{{Path: "not a valid gnmi path!!"}}); err == nil {
+		t.Error("expected an error for an invalid path, got nil")
+	}
+}
+
+func TestBroadcastDropsOldestWhenCollectorQueueIsFull(t *testing.T) {
+	q := make(chan *pb.SubscribeResponse, 2)
+	first := &pb.SubscribeResponse{Response: &pb.SubscribeResponse_SyncResponse{SyncResponse: true}}
+	second := &pb.SubscribeResponse{Response: &pb.SubscribeResponse_SyncResponse{SyncResponse: true}}
+	third := &pb.SubscribeResponse{Response: &pb.SubscribeResponse_SyncResponse{SyncResponse: true}}
+
+	broadcast([]chan *pb.SubscribeResponse{q}, first)
+	broadcast([]chan *pb.SubscribeResponse{q}, second)
+	broadcast([]chan *pb.SubscribeResponse{q}, third) // queue is full; should drop `first`, not `third`
+
+	if got := <-q; got != second {
+		t.Errorf("first dequeued response = %p, want the second broadcast one (%p)", got, second)
+	}
+	if got := <-q; got != third {
+		t.Errorf("second dequeued response = %p, want the third broadcast one (%p)", got, third)
+	}
+}
+
+func TestNextBackoffDoublesAndCaps(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want time.Duration
+	}{
+		{time.Second, 2 * time.Second},
+		{10 * time.Second, 20 * time.Second},
+		{20 * time.Second, 40 * time.Second},
+		{maxDialOutBackoff, maxDialOutBackoff},
+	}
+	for _, tt := range tests {
+		if got := nextBackoff(tt.in); got != tt.want {
+			t.Errorf("nextBackoff(%s) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCollectorDialOptionsInsecure(t *testing.T) {
+	opts, err := collectorDialOptions(dialOutCollectorConfig{Address: "collector:9339", Insecure: true})
+	if err != nil {
+		t.Fatalf("collectorDialOptions() error = %v", err)
+	}
+	if len(opts) != 1 {
+		t.Errorf("len(opts) = %d, want 1", len(opts))
+	}
+}
+
+func TestCollectorDialOptionsTLSWithMissingClientCertErrors(t *testing.T) {
+	_, err := collectorDialOptions(dialOutCollectorConfig{
+		Address:  "collector:9339",
+		CertFile: "/no/such/cert.pem",
+		KeyFile:  "/no/such/key.pem",
+	})
+	if err == nil {
+		t.Error("expected an error loading a nonexistent client certificate, got nil")
+	}
+}
+
+func TestCollectorDialOptionsTLSWithCAFile(t *testing.T) {
+	caFile, err := ioutil.TempFile("", "collector-ca-*.pem")
+	if err != nil {
+		t.Fatalf("failed to create temp CA file: %v", err)
+	}
+	defer os.Remove(caFile.Name())
+
+	opts, err := collectorDialOptions(dialOutCollectorConfig{Address: "collector:9339", CAFile: caFile.Name(), ServerName: "collector.example.com"})
+	if err != nil {
+		t.Fatalf("collectorDialOptions() error = %v", err)
+	}
+	if len(opts) != 1 {
+		t.Errorf("len(opts) = %d, want 1", len(opts))
+	}
+}
+
+func TestDialOutSubscribeStreamDeliversTheSubscriptionOnceThenBlocks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sub := &pb.SubscriptionList{Mode: pb.SubscriptionList_STREAM}
+	stream := newDialOutSubscribeStream(ctx, sub)
+
+	req, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("first Recv() error = %v", err)
+	}
+	if req.GetSubscribe() != sub {
+		t.Errorf("first Recv() did not deliver the configured SubscriptionList")
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := stream.Recv()
+		done <- err
+	}()
+
+	select {
+	case <-done:
+		t.Error("second Recv() returned before the stream's context was cancelled")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("second Recv() error after cancel = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("second Recv() did not unblock after the context was cancelled")
+	}
+}
+
+func TestDialOutSubscribeStreamSendDeliversToResponses(t *testing.T) {
+	ctx := context.Background()
+	stream := newDialOutSubscribeStream(ctx, &pb.SubscriptionList{})
+
+	resp := &pb.SubscribeResponse{Response: &pb.SubscribeResponse_SyncResponse{SyncResponse: true}}
+	if err := stream.Send(resp); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	select {
+	case got := <-stream.responses:
+		if got != resp {
+			t.Errorf("responses channel delivered a different message than was sent")
+		}
+	default:
+		t.Error("Send() did not enqueue the response")
+	}
+}
@@ -15,6 +15,7 @@
 package cmd
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"flag"
@@ -22,12 +23,20 @@ import (
 	"github.com/pkg/errors"
 	"io/ioutil"
 	"net"
+	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 
+	"github.com/onosproject/gnmi-netconf-adapter/pkg/adapter/authz"
+	"github.com/onosproject/gnmi-netconf-adapter/pkg/adapter/creds"
+	"github.com/onosproject/gnmi-netconf-adapter/pkg/adapter/telemetry"
 	pb "github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/spf13/cobra"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	grpccredentials "google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 	log "k8s.io/klog"
 )
@@ -38,10 +47,32 @@ var (
 	cert       *string
 	isInsecure *bool
 	port       *int
-	// The initial prototype only supports one device per adapter instance
-	deviceIP       *string
-	deviceUsername *string
-	devicePassword *string
+	// deviceIP/deviceUsername/devicePassword configure a single device, used when deviceInventory is
+	// unset; deviceInventory configures an arbitrary set of devices routed by gNMI target (see
+	// adapter.DeviceRegistry), reloaded on SIGHUP. credentialsConfigPath, when set, overrides
+	// deviceUsername/devicePassword with a pluggable creds.Provider (see cmd/credentials.go).
+	deviceIP              *string
+	deviceUsername        *string
+	devicePassword        *string
+	credentialsConfigPath *string
+	yangDir               *string
+	deviceInventory       *string
+	targetInventory       *string
+
+	// authzPolicy points at an RBAC policy file granting identities (client cert CN/SAN) access to
+	// gNMI RPCs and paths; when unset, no authorization interceptor is installed and any
+	// authenticated client may use the full API.
+	authzPolicy *string
+
+	// oidcConfig points at a YAML file configuring OIDC/JWT bearer-token authentication (see
+	// cmd/oidc.go); when unset, no OIDC guard is installed. It composes with authzPolicy: a caller
+	// must satisfy both when both are configured.
+	oidcConfig *string
+
+	otelExporter    *string
+	otelEndpoint    *string
+	otelServiceName *string
+	otelSampleRatio *float64
 )
 
 // serverCmd represents the server command
@@ -64,6 +95,19 @@ func init() {
 	deviceIP = serverCmd.Flags().String("device-ip", "10.228.63.5:830", "device ip address:port for NETCONF")
 	deviceUsername = serverCmd.Flags().String("device-user", "", "device NETCONF username")
 	devicePassword = serverCmd.Flags().String("device-pass", "", "device NETCONF password")
+	credentialsConfigPath = serverCmd.Flags().String("credentials-config", "", "YAML file selecting a pluggable credential provider (static/keyfile/exec/file/url) for the NETCONF session; overrides --device-user/--device-pass when set")
+	yangDir = serverCmd.Flags().String("yang-dir", "", "directory of vendor-pack YANG modules to load into the model registry; when unset the compiled-in Junos model is used")
+	deviceInventory = serverCmd.Flags().String("device-inventory", "", "YAML/JSON file listing multiple NETCONF devices to front, routed by gNMI target; overrides --device-ip/--device-user/--device-pass when set, and is re-read on SIGHUP")
+	targetInventory = serverCmd.Flags().String("target-inventory", "", "YAML file listing multiple NETCONF devices to front, each bound to a named YANG model loaded from --yang-dir and routed by gNMI target; unlike --device-inventory, different targets may run different schemas. Overrides --device-inventory/--device-ip when set")
+
+	authzPolicy = serverCmd.Flags().String("authz-policy", "", "JSON file granting identities (client cert CN/SAN) access to gNMI RPCs and paths; when unset, any authenticated client may use the full API; the file is hot-reloaded on change")
+
+	oidcConfig = serverCmd.Flags().String("oidc-config", "", "YAML file configuring OIDC/JWT bearer-token authentication and group-based RBAC (see cmd/oidc.go); when unset, no OIDC guard is installed")
+
+	otelExporter = serverCmd.Flags().String("otel-exporter", "none", "OpenTelemetry trace exporter to use: none, otlp-http, otlp-grpc, jaeger")
+	otelEndpoint = serverCmd.Flags().String("otel-endpoint", "", "collector endpoint for the selected OpenTelemetry exporter")
+	otelServiceName = serverCmd.Flags().String("otel-service-name", "gnmi-netconf-adapter", "service.name reported on exported traces")
+	otelSampleRatio = serverCmd.Flags().Float64("otel-sample-ratio", 1.0, "fraction, in [0,1], of traces without a sampled parent that are recorded")
 
 	rootCmd.AddCommand(serverCmd)
 }
@@ -105,6 +149,21 @@ func RunGnmiServer(command *cobra.Command, args []string) {
 
 // Serve starts the NB gNMI server.
 func Serve(started func(string)) error {
+	shutdownTelemetry, err := telemetry.Init(context.Background(), telemetry.Config{
+		Exporter:    telemetry.Exporter(*otelExporter),
+		Endpoint:    *otelEndpoint,
+		ServiceName: *otelServiceName,
+		SampleRatio: *otelSampleRatio,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to initialise OpenTelemetry")
+	}
+	defer func() {
+		if err := shutdownTelemetry(context.Background()); err != nil {
+			log.Warningf("error shutting down OpenTelemetry: %v", err)
+		}
+	}()
+
 	lis, err := net.Listen("tcp", ":"+strconv.Itoa(*port))
 	if err != nil {
 		return err
@@ -124,22 +183,120 @@ func Serve(started func(string)) error {
 		tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
 	}
 
+	unaryInterceptors := []grpc.UnaryServerInterceptor{otelgrpc.UnaryServerInterceptor()}
+	streamInterceptors := []grpc.StreamServerInterceptor{otelgrpc.StreamServerInterceptor()}
+
 	opts := []grpc.ServerOption{grpc.Creds(grpccredentials.NewTLS(tlsCfg))}
+	if *authzPolicy != "" {
+		authorizer, err := authz.NewAuthorizer(*authzPolicy)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load authz policy %s", *authzPolicy)
+		}
+		defer func() {
+			if err := authorizer.Close(); err != nil {
+				log.Warningf("error closing authz policy watcher: %v", err)
+			}
+		}()
+		unaryInterceptors = append(unaryInterceptors, authorizer.UnaryInterceptor())
+		streamInterceptors = append(streamInterceptors, authorizer.StreamInterceptor())
+	}
+	if *oidcConfig != "" {
+		guard, err := loadOIDCGuard(*oidcConfig)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load OIDC config %s", *oidcConfig)
+		}
+		unaryInterceptors = append(unaryInterceptors, guard.UnaryInterceptor())
+		streamInterceptors = append(streamInterceptors, guard.StreamInterceptor())
+	}
+	opts = append(opts, grpc.UnaryInterceptor(chainUnaryInterceptors(unaryInterceptors)), grpc.StreamInterceptor(chainStreamInterceptors(streamInterceptors)))
 	grpcServer := grpc.NewServer(opts...)
 
-	s, err := newGnmiServer(model, *deviceIP, *deviceUsername, *devicePassword)
+	var s pb.GNMIServer
+	var inventoryPath string
+	if *targetInventory != "" {
+		s, err = newGnmiTargetResolverServer(*yangDir, *targetInventory)
+		inventoryPath = *targetInventory
+	} else if *deviceInventory != "" {
+		s, err = newGnmiDeviceRegistryServer(model, *deviceInventory)
+		inventoryPath = *deviceInventory
+	} else {
+		provider := creds.Provider(creds.NewStaticProvider(*deviceUsername, *devicePassword))
+		if *credentialsConfigPath != "" {
+			provider, err = loadCredentialProvider(*credentialsConfigPath)
+			if err != nil {
+				return errors.Wrapf(err, "failed to load credentials config %s", *credentialsConfigPath)
+			}
+		}
+		s, err = newGnmiServer(model, *deviceIP, provider, *yangDir)
+	}
 	if err != nil {
 		return err
 	}
 
 	pb.RegisterGNMIServer(grpcServer, s)
+	if healthy, ok := s.(interface {
+		HealthServer() grpc_health_v1.HealthServer
+	}); ok {
+		grpc_health_v1.RegisterHealthServer(grpcServer, healthy.HealthServer())
+	}
 	reflection.Register(grpcServer)
 
+	if reloadable, ok := s.(interface {
+		ReloadInventory(path string) error
+	}); ok {
+		watchInventoryReload(inventoryPath, reloadable)
+	}
+
 	message := fmt.Sprintf("Listening on %s with session opened to NETCONF device at %s", lis.Addr(), *deviceIP)
 	started(message)
 	return grpcServer.Serve(lis)
 }
 
+// chainUnaryInterceptors composes interceptors into a single grpc.UnaryServerInterceptor that runs
+// them in order, each wrapping the next, with the gRPC handler innermost.
+func chainUnaryInterceptors(interceptors []grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		chained := handler
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, next := interceptors[i], chained
+			chained = func(ctx context.Context, req interface{}) (interface{}, error) {
+				return interceptor(ctx, req, info, next)
+			}
+		}
+		return chained(ctx, req)
+	}
+}
+
+// chainStreamInterceptors composes interceptors into a single grpc.StreamServerInterceptor that
+// runs them in order, each wrapping the next, with the gRPC handler innermost.
+func chainStreamInterceptors(interceptors []grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		chained := handler
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, next := interceptors[i], chained
+			chained = func(srv interface{}, ss grpc.ServerStream) error {
+				return interceptor(srv, ss, info, next)
+			}
+		}
+		return chained(srv, ss)
+	}
+}
+
+// watchInventoryReload re-reads the device inventory at path into s whenever the process receives
+// SIGHUP, so devices can be added or removed without restarting the server.
+func watchInventoryReload(path string, s interface{ ReloadInventory(path string) error }) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	go func() {
+		for range sighup {
+			log.Infof("SIGHUP received, reloading device inventory from %s", path)
+			if err := s.ReloadInventory(path); err != nil {
+				log.Errorf("failed to reload device inventory: %v", err)
+			}
+		}
+	}()
+}
+
 func getCertPool(CaPath string) *x509.CertPool {
 	certPool := x509.NewCertPool()
 	ca, err := ioutil.ReadFile(CaPath)
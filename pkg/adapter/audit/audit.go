@@ -0,0 +1,92 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit publishes structured audit events for the gNMI RPCs the adapter serves, through a
+// pluggable Emitter so operators can route them to whatever log pipeline they already run (stdout
+// JSONL for a sidecar to scrape, syslog for a central collector, or nowhere at all) without the
+// adapter core knowing which.
+package audit
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/onosproject/gnmi-netconf-adapter/pkg/adapter/oidcauth"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// GetEvent records a single gNMI Get RPC.
+type GetEvent struct {
+	Identity string
+	Paths    []string
+	Code     string
+	Duration time.Duration
+}
+
+// SetEvent records a single NETCONF edit issued on behalf of a gNMI Set RPC.
+type SetEvent struct {
+	Identity      string
+	Path          string
+	NetconfFilter string
+	Code          string
+	Duration      time.Duration
+}
+
+// SubscribeEvent records a single gNMI Subscribe RPC, from the client's first SubscriptionList to
+// the stream closing.
+type SubscribeEvent struct {
+	Identity string
+	Paths    []string
+	Code     string
+	Duration time.Duration
+}
+
+// Emitter publishes audit events for the gNMI RPCs the adapter serves. Implementations must be safe
+// for concurrent use, since RPCs are served concurrently.
+type Emitter interface {
+	EmitGetEvent(ctx context.Context, e GetEvent)
+	EmitSetEvent(ctx context.Context, e SetEvent)
+	EmitSubscribeEvent(ctx context.Context, e SubscribeEvent)
+}
+
+// IdentityFromContext returns the best available identity for the peer making an RPC: a verified
+// client certificate's CN (falling back to its first SAN), or failing that the OIDC identity an
+// oidcauth.Guard stashed in ctx (subject, plus groups when it has any), or failing that the peer's
+// network address, or "unknown" if ctx carries none of the above. Unlike authz's identity
+// resolution, this never fails an RPC - an unauthenticated or unidentifiable peer is still audited.
+func IdentityFromContext(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok {
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(tlsInfo.State.VerifiedChains) > 0 && len(tlsInfo.State.VerifiedChains[0]) > 0 {
+			cert := tlsInfo.State.VerifiedChains[0][0]
+			if cert.Subject.CommonName != "" {
+				return cert.Subject.CommonName
+			}
+			if len(cert.DNSNames) > 0 {
+				return cert.DNSNames[0]
+			}
+		}
+	}
+	if identity, ok := oidcauth.IdentityFromContext(ctx); ok {
+		if len(identity.Groups) > 0 {
+			return identity.Subject + " [" + strings.Join(identity.Groups, ",") + "]"
+		}
+		return identity.Subject
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return "unknown"
+}
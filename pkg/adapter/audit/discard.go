@@ -0,0 +1,27 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import "context"
+
+// Discard is an Emitter that drops every event. It is useful as an explicit, self-documenting
+// configuration choice where audit.Emitter is required but no audit trail is wanted.
+var Discard Emitter = discardEmitter{}
+
+type discardEmitter struct{}
+
+func (discardEmitter) EmitGetEvent(ctx context.Context, e GetEvent)             {}
+func (discardEmitter) EmitSetEvent(ctx context.Context, e SetEvent)             {}
+func (discardEmitter) EmitSubscribeEvent(ctx context.Context, e SubscribeEvent) {}
@@ -0,0 +1,71 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	log "k8s.io/klog"
+)
+
+// JSONLEmitter writes one JSON object per line to an io.Writer (os.Stdout by default), suitable for
+// a log-shipping sidecar to tail.
+type JSONLEmitter struct {
+	w  io.Writer
+	mu sync.Mutex
+}
+
+// NewJSONLEmitter returns an Emitter that writes newline-delimited JSON to w. A nil w writes to
+// os.Stdout.
+func NewJSONLEmitter(w io.Writer) *JSONLEmitter {
+	if w == nil {
+		w = os.Stdout
+	}
+	return &JSONLEmitter{w: w}
+}
+
+// jsonlRecord adds the RPC name to an event so every line is self-describing without a schema.
+type jsonlRecord struct {
+	RPC string `json:"rpc"`
+	Event interface{} `json:"event"`
+}
+
+func (e *JSONLEmitter) write(rpc string, event interface{}) {
+	line, err := json.Marshal(jsonlRecord{RPC: rpc, Event: event})
+	if err != nil {
+		log.Errorf("failed to marshal %s audit event: %v", rpc, err)
+		return
+	}
+	line = append(line, '\n')
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, err := e.w.Write(line); err != nil {
+		log.Errorf("failed to write %s audit event: %v", rpc, err)
+	}
+}
+
+// EmitGetEvent implements Emitter.
+func (e *JSONLEmitter) EmitGetEvent(ctx context.Context, ev GetEvent) { e.write("Get", ev) }
+
+// EmitSetEvent implements Emitter.
+func (e *JSONLEmitter) EmitSetEvent(ctx context.Context, ev SetEvent) { e.write("Set", ev) }
+
+// EmitSubscribeEvent implements Emitter.
+func (e *JSONLEmitter) EmitSubscribeEvent(ctx context.Context, ev SubscribeEvent) { e.write("Subscribe", ev) }
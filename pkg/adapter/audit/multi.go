@@ -0,0 +1,47 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import "context"
+
+// MultiEmitter fans every event out to a fixed set of Emitters, e.g. to audit both to stdout and a
+// syslog collector at once.
+type MultiEmitter []Emitter
+
+// NewMultiEmitter returns an Emitter that publishes every event to each of emitters in turn.
+func NewMultiEmitter(emitters ...Emitter) MultiEmitter {
+	return MultiEmitter(emitters)
+}
+
+// EmitGetEvent implements Emitter.
+func (m MultiEmitter) EmitGetEvent(ctx context.Context, e GetEvent) {
+	for _, emitter := range m {
+		emitter.EmitGetEvent(ctx, e)
+	}
+}
+
+// EmitSetEvent implements Emitter.
+func (m MultiEmitter) EmitSetEvent(ctx context.Context, e SetEvent) {
+	for _, emitter := range m {
+		emitter.EmitSetEvent(ctx, e)
+	}
+}
+
+// EmitSubscribeEvent implements Emitter.
+func (m MultiEmitter) EmitSubscribeEvent(ctx context.Context, e SubscribeEvent) {
+	for _, emitter := range m {
+		emitter.EmitSubscribeEvent(ctx, e)
+	}
+}
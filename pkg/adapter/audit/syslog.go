@@ -0,0 +1,133 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	log "k8s.io/klog"
+)
+
+// Facility is an RFC5424 syslog facility code.
+type Facility int
+
+// Facilities commonly used for application audit trails; see RFC 5424 section 6.2.1 for the full
+// table.
+const (
+	FacilityUser   Facility = 1
+	FacilityLocal0 Facility = 16
+	FacilityLocal1 Facility = 17
+	FacilityAuth   Facility = 10
+)
+
+// severityInfo is the RFC5424 severity every audit record is sent at: these are routine operational
+// records, not alerts.
+const severityInfo = 6
+
+// SyslogEmitter sends each event as a single RFC 5424 syslog message, with the event itself encoded
+// as JSON in the message body.
+type SyslogEmitter struct {
+	network  string // "udp", "tcp", or "tls"
+	addr     string
+	facility Facility
+	hostname string
+	appName  string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewSyslogEmitter dials a syslog collector at addr over network ("udp", "tcp", or "tls"), tagging
+// every message with facility. The connection is dialed lazily on first use and redialed on write
+// failure.
+func NewSyslogEmitter(network, addr string, facility Facility) (*SyslogEmitter, error) {
+	switch network {
+	case "udp", "tcp", "tls":
+	default:
+		return nil, fmt.Errorf("unsupported syslog network %q: want udp, tcp, or tls", network)
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "-"
+	}
+	return &SyslogEmitter{network: network, addr: addr, facility: facility, hostname: hostname, appName: "gnmi-netconf-adapter"}, nil
+}
+
+func (e *SyslogEmitter) dial() (net.Conn, error) {
+	if e.network == "tls" {
+		return tls.Dial("tcp", e.addr, nil)
+	}
+	return net.Dial(e.network, e.addr)
+}
+
+// send formats event as the body of an RFC 5424 message and writes it to the collector, dialing (or
+// redialing, after a prior write failure) as needed.
+func (e *SyslogEmitter) send(rpc string, event interface{}) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Errorf("failed to marshal %s audit event: %v", rpc, err)
+		return
+	}
+
+	// RFC 5424: <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+	priority := int(e.facility)*8 + severityInfo
+	msg := fmt.Sprintf("<%d>1 %s %s %s %d %s - %s\n",
+		priority, time.Now().UTC().Format(time.RFC3339), e.hostname, e.appName, os.Getpid(), rpc, body)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.conn == nil {
+		conn, err := e.dial()
+		if err != nil {
+			log.Errorf("failed to dial syslog collector %s: %v", e.addr, err)
+			return
+		}
+		e.conn = conn
+	}
+	if _, err := e.conn.Write([]byte(msg)); err != nil {
+		log.Errorf("failed to write %s audit event to syslog collector %s: %v", rpc, e.addr, err)
+		_ = e.conn.Close()
+		e.conn = nil
+	}
+}
+
+// EmitGetEvent implements Emitter.
+func (e *SyslogEmitter) EmitGetEvent(ctx context.Context, ev GetEvent) { e.send("Get", ev) }
+
+// EmitSetEvent implements Emitter.
+func (e *SyslogEmitter) EmitSetEvent(ctx context.Context, ev SetEvent) { e.send("Set", ev) }
+
+// EmitSubscribeEvent implements Emitter.
+func (e *SyslogEmitter) EmitSubscribeEvent(ctx context.Context, ev SubscribeEvent) { e.send("Subscribe", ev) }
+
+// Close closes the underlying connection to the syslog collector, if one is open.
+func (e *SyslogEmitter) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.conn == nil {
+		return nil
+	}
+	err := e.conn.Close()
+	e.conn = nil
+	return err
+}
@@ -0,0 +1,110 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authz
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+	log "k8s.io/klog"
+)
+
+// Authorizer holds the Policy loaded from a file, hot-reloading it whenever the file changes. The
+// current snapshot is published through an atomic.Value so that a concurrent reload can never leave
+// an in-flight RPC seeing a partially-updated Policy.
+type Authorizer struct {
+	path    string
+	current atomic.Value // *Policy
+	watcher *fsnotify.Watcher
+}
+
+// NewAuthorizer loads the policy at path and starts watching its directory for changes to it,
+// reloading the Policy on every write. Callers should Close the returned Authorizer once it is no
+// longer needed, to stop the watcher goroutine.
+func NewAuthorizer(path string) (*Authorizer, error) {
+	a := &Authorizer{path: path}
+	if err := a.reload(); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create authz policy file watcher")
+	}
+	// Watch the containing directory, not the file itself: editors commonly replace a file rather
+	// than writing it in place, which re-points the path at a new inode fsnotify would otherwise lose
+	// its watch on.
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		_ = watcher.Close()
+		return nil, errors.Wrapf(err, "failed to watch directory of authz policy %s", path)
+	}
+	a.watcher = watcher
+	go a.watch()
+	return a, nil
+}
+
+func (a *Authorizer) watch() {
+	target := filepath.Clean(a.path)
+	for {
+		select {
+		case event, ok := <-a.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != target || event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+			if err := a.reload(); err != nil {
+				log.Errorf("failed to reload authz policy %s: %v", a.path, err)
+			}
+		case err, ok := <-a.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Errorf("authz policy watcher error for %s: %v", a.path, err)
+		}
+	}
+}
+
+func (a *Authorizer) reload() error {
+	raw, err := ioutil.ReadFile(a.path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read authz policy %s", a.path)
+	}
+	var policy Policy
+	if err := json.Unmarshal(raw, &policy); err != nil {
+		return errors.Wrapf(err, "failed to parse authz policy %s", a.path)
+	}
+	a.current.Store(&policy)
+	return nil
+}
+
+// Policy returns the currently loaded policy snapshot.
+func (a *Authorizer) Policy() *Policy {
+	p, _ := a.current.Load().(*Policy)
+	return p
+}
+
+// Close stops watching the policy file for changes.
+func (a *Authorizer) Close() error {
+	if a.watcher == nil {
+		return nil
+	}
+	return a.watcher.Close()
+}
@@ -0,0 +1,178 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authz
+
+import (
+	"context"
+	"strings"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryInterceptor authorizes unary RPCs (Capabilities, Get, Set) against a's current policy. Get
+// and Set are checked path-by-path, each against the rule for its RPC name; Capabilities carries no
+// path and is always allowed once the peer's identity has been established.
+func (a *Authorizer) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		identity, err := identityFromContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		rpc := rpcName(info.FullMethod)
+		policy := a.Policy()
+		for _, check := range requestChecks(req) {
+			if !policy.Allow(identity, rpc, check.path, check.op) {
+				return nil, status.Errorf(codes.PermissionDenied, "%q is not permitted to %s %s via %s", identity, check.op, check.path, rpc)
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor authorizes streaming RPCs (Subscribe). Each SubscribeRequest received on the
+// stream - the initial SubscriptionList and any subsequent poll trigger - is checked before being
+// delivered to the handler.
+func (a *Authorizer) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		identity, err := identityFromContext(ss.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authorizingServerStream{
+			ServerStream: ss,
+			authorizer:   a,
+			identity:     identity,
+			rpc:          rpcName(info.FullMethod),
+		})
+	}
+}
+
+// authorizingServerStream wraps a grpc.ServerStream to authorize every message as it is received.
+type authorizingServerStream struct {
+	grpc.ServerStream
+	authorizer *Authorizer
+	identity   string
+	rpc        string
+}
+
+func (s *authorizingServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	policy := s.authorizer.Policy()
+	for _, check := range requestChecks(m) {
+		if !policy.Allow(s.identity, s.rpc, check.path, check.op) {
+			return status.Errorf(codes.PermissionDenied, "%q is not permitted to %s %s via %s", s.identity, check.op, check.path, s.rpc)
+		}
+	}
+	return nil
+}
+
+// identityFromContext extracts the verified client certificate's CN - falling back to its first DNS
+// SAN when it has no CN - from ctx's peer info, as the identity a Policy's rules are keyed on.
+func identityFromContext(ctx context.Context) (string, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", status.Error(codes.Unauthenticated, "no peer information in context")
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return "", status.Error(codes.Unauthenticated, "no verified client certificate in context")
+	}
+	cert := tlsInfo.State.VerifiedChains[0][0]
+	if cert.Subject.CommonName != "" {
+		return cert.Subject.CommonName, nil
+	}
+	if len(cert.DNSNames) > 0 {
+		return cert.DNSNames[0], nil
+	}
+	return "", status.Error(codes.Unauthenticated, "client certificate has neither a CN nor a SAN")
+}
+
+// rpcName returns the short RPC name (e.g. "Set") from a gRPC FullMethod (e.g. "/gnmi.gNMI/Set").
+func rpcName(fullMethod string) string {
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		return fullMethod[i+1:]
+	}
+	return fullMethod
+}
+
+// pathCheck is one (path, op) pair that must be allowed by the current policy for a request to
+// proceed.
+type pathCheck struct {
+	path string
+	op   string
+}
+
+// requestChecks derives the path/op pairs that must be authorized for req, based on its gNMI message
+// type. Request types that carry no path (e.g. CapabilityRequest) require no checks.
+func requestChecks(req interface{}) []pathCheck {
+	switch r := req.(type) {
+	case *gnmi.GetRequest:
+		checks := make([]pathCheck, 0, len(r.GetPath()))
+		for _, p := range r.GetPath() {
+			checks = append(checks, pathCheck{path: gnmiPathString(r.GetPrefix(), p), op: "read"})
+		}
+		return checks
+	case *gnmi.SetRequest:
+		var checks []pathCheck
+		for _, p := range r.GetDelete() {
+			checks = append(checks, pathCheck{path: gnmiPathString(r.GetPrefix(), p), op: "delete"})
+		}
+		for _, u := range r.GetReplace() {
+			checks = append(checks, pathCheck{path: gnmiPathString(r.GetPrefix(), u.GetPath()), op: "update"})
+		}
+		for _, u := range r.GetUpdate() {
+			checks = append(checks, pathCheck{path: gnmiPathString(r.GetPrefix(), u.GetPath()), op: "update"})
+		}
+		return checks
+	case *gnmi.SubscribeRequest:
+		list := r.GetSubscribe()
+		if list == nil {
+			return nil
+		}
+		checks := make([]pathCheck, 0, len(list.GetSubscription()))
+		for _, sub := range list.GetSubscription() {
+			checks = append(checks, pathCheck{path: gnmiPathString(list.GetPrefix(), sub.GetPath()), op: "read"})
+		}
+		return checks
+	default:
+		return nil
+	}
+}
+
+// gnmiPathString renders a gNMI path, with prefix folded in, as a slash-separated string such as
+// "/configuration/system/services", for comparison against a Rule's PathPrefix.
+func gnmiPathString(prefix, path *gnmi.Path) string {
+	var b strings.Builder
+	for _, elem := range prefix.GetElem() {
+		b.WriteByte('/')
+		b.WriteString(elem.GetName())
+	}
+	for _, elem := range path.GetElem() {
+		b.WriteByte('/')
+		b.WriteString(elem.GetName())
+	}
+	if b.Len() == 0 {
+		return "/"
+	}
+	return b.String()
+}
@@ -0,0 +1,150 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package authz
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	assert "github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// contextForIdentity builds a context carrying peer info as if the client had presented a
+// certificate with the given CN (or, if cn is empty, the given SAN), mirroring what grpc's TLS
+// transport credentials populate for a verified client cert.
+func contextForIdentity(cn string, san string) context.Context {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: cn}}
+	if san != "" {
+		cert.DNSNames = []string{san}
+	}
+	p := &peer.Peer{
+		AuthInfo: credentials.TLSInfo{
+			State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}},
+		},
+	}
+	return peer.NewContext(context.Background(), p)
+}
+
+func newTestAuthorizer(t *testing.T, policy string) *Authorizer {
+	f, err := ioutil.TempFile("", "authz-policy-*.json")
+	assert.NoError(t, err)
+	_, err = f.WriteString(policy)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+	t.Cleanup(func() { _ = os.Remove(f.Name()) })
+
+	a, err := NewAuthorizer(f.Name())
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = a.Close() })
+	return a
+}
+
+const testPolicyJSON = `{
+	"identities": {
+		"alice": [
+			{"rpc": "Set", "path_prefix": "/configuration/system", "ops": ["update", "delete"]},
+			{"rpc": "Get", "path_prefix": "/", "ops": ["read"]}
+		],
+		"device-42.example.com": [
+			{"rpc": "Set", "path_prefix": "/configuration", "ops": ["update"]}
+		]
+	}
+}`
+
+func TestUnaryInterceptorSet(t *testing.T) {
+	a := newTestAuthorizer(t, testPolicyJSON)
+	handlerCalled := false
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		handlerCalled = true
+		return nil, nil
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/gnmi.gNMI/Set"}
+
+	tests := []struct {
+		desc     string
+		ctx      context.Context
+		req      *gnmi.SetRequest
+		wantCode codes.Code
+	}{
+		{
+			desc: "alice may update under her path prefix",
+			ctx:  contextForIdentity("alice", ""),
+			req: &gnmi.SetRequest{
+				Prefix:  &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "configuration"}, {Name: "system"}}},
+				Replace: []*gnmi.Update{{Path: &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "hostname"}}}}},
+			},
+			wantCode: codes.OK,
+		},
+		{
+			desc: "alice may not delete outside her path prefix",
+			ctx:  contextForIdentity("alice", ""),
+			req: &gnmi.SetRequest{
+				Delete: []*gnmi.Path{{Elem: []*gnmi.PathElem{{Name: "configuration"}, {Name: "interfaces"}}}},
+			},
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			desc: "a SAN-only identity is honored when it has no CN",
+			ctx:  contextForIdentity("", "device-42.example.com"),
+			req: &gnmi.SetRequest{
+				Update: []*gnmi.Update{{Path: &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "configuration"}, {Name: "system"}}}}},
+			},
+			wantCode: codes.OK,
+		},
+		{
+			desc: "an identity with no rules is denied by default",
+			ctx:  contextForIdentity("mallory", ""),
+			req: &gnmi.SetRequest{
+				Update: []*gnmi.Update{{Path: &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "configuration"}}}}},
+			},
+			wantCode: codes.PermissionDenied,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			handlerCalled = false
+			_, err := a.UnaryInterceptor()(tc.ctx, tc.req, info, handler)
+			st, ok := status.FromError(err)
+			assert.True(t, ok || err == nil)
+			if err == nil {
+				st = status.New(codes.OK, "")
+			}
+			assert.Equal(t, tc.wantCode, st.Code())
+			assert.Equal(t, tc.wantCode == codes.OK, handlerCalled)
+		})
+	}
+}
+
+func TestUnaryInterceptorRequiresVerifiedPeer(t *testing.T) {
+	a := newTestAuthorizer(t, testPolicyJSON)
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil }
+	info := &grpc.UnaryServerInfo{FullMethod: "/gnmi.gNMI/Get"}
+
+	_, err := a.UnaryInterceptor()(context.Background(), &gnmi.GetRequest{}, info, handler)
+	st, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.Unauthenticated, st.Code())
+}
@@ -0,0 +1,57 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package authz implements peer-identity-based RBAC authorization for gNMI RPCs, enforced by a gRPC
+// interceptor (see Authorizer.UnaryInterceptor/StreamInterceptor) against a policy file that is
+// hot-reloaded on edit (see NewAuthorizer).
+package authz
+
+import "strings"
+
+// Rule grants an identity permission to perform any of Ops against paths under PathPrefix when
+// calling RPC.
+type Rule struct {
+	RPC        string   `json:"rpc"`
+	PathPrefix string   `json:"path_prefix"`
+	Ops        []string `json:"ops"`
+}
+
+// Policy binds peer identities - a verified client certificate's CN, or a SAN when it has no CN - to
+// the Rules they are allowed. A request is denied unless some rule explicitly allows it; there is no
+// default-allow.
+type Policy struct {
+	Identities map[string][]Rule `json:"identities"`
+}
+
+// Allow reports whether identity may perform op against path when calling rpc, per p's rules. A nil
+// Policy allows nothing.
+func (p *Policy) Allow(identity, rpc, path, op string) bool {
+	if p == nil {
+		return false
+	}
+	for _, rule := range p.Identities[identity] {
+		if rule.RPC != rpc {
+			continue
+		}
+		if !strings.HasPrefix(path, rule.PathPrefix) {
+			continue
+		}
+		for _, allowed := range rule.Ops {
+			if allowed == op {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package authz
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func testPolicy() *Policy {
+	return &Policy{
+		Identities: map[string][]Rule{
+			"alice": {
+				{RPC: "Set", PathPrefix: "/configuration/system", Ops: []string{"update", "delete"}},
+				{RPC: "Get", PathPrefix: "/", Ops: []string{"read"}},
+			},
+			"bob": {
+				{RPC: "Get", PathPrefix: "/configuration/interfaces", Ops: []string{"read"}},
+			},
+		},
+	}
+}
+
+func TestPolicyAllow(t *testing.T) {
+	tests := []struct {
+		desc     string
+		identity string
+		rpc      string
+		path     string
+		op       string
+		want     bool
+	}{
+		{"alice may update under her path prefix", "alice", "Set", "/configuration/system/services/ssh", "update", true},
+		{"alice may delete under her path prefix", "alice", "Set", "/configuration/system/services/ssh", "delete", true},
+		{"alice may not update outside her path prefix", "alice", "Set", "/configuration/interfaces/eth0", "update", false},
+		{"alice may not replace (unlisted op)", "alice", "Set", "/configuration/system/services/ssh", "replace", false},
+		{"alice may read anything", "alice", "Get", "/configuration/interfaces/eth0", "read", true},
+		{"bob may read under his path prefix", "bob", "Get", "/configuration/interfaces/eth0", "read", true},
+		{"bob may not read outside his path prefix", "bob", "Get", "/configuration/system", "read", false},
+		{"bob may not set at all", "bob", "Set", "/configuration/interfaces/eth0", "update", false},
+		{"unknown identity is denied", "mallory", "Get", "/", "read", false},
+	}
+
+	policy := testPolicy()
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			assert.Equal(t, tc.want, policy.Allow(tc.identity, tc.rpc, tc.path, tc.op))
+		})
+	}
+}
+
+func TestNilPolicyDeniesEverything(t *testing.T) {
+	var policy *Policy
+	assert.False(t, policy.Allow("alice", "Get", "/", "read"))
+}
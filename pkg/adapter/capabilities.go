@@ -0,0 +1,51 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package adapter implements a gnmi server that adapts to a netconf device.
+package adapter
+
+import (
+	"github.com/damianoneill/net/v2/netconf/ops"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// model is a shared fixture populated directly by tests in this package (see e.g. TestGet) that need
+// a Model built from the compiled-in schema but are not themselves exercising NewAdapter.
+var model *Model
+
+// NewAdapter returns a new Adapter, implementing gnmi.GNMIServer, bound to the supplied model and
+// NETCONF session. opts configure optional behaviour, e.g. WithAuditEmitter.
+func NewAdapter(m *Model, ncs ops.OpSession, opts ...AdapterOption) (gnmi.GNMIServer, error) {
+	a := &Adapter{model: m, ncs: ncs}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a, nil
+}
+
+// Capabilities implements the Capabilities RPC in gNMI spec.
+func (a *Adapter) Capabilities(ctx context.Context, req *gnmi.CapabilityRequest) (*gnmi.CapabilityResponse, error) {
+	ver, err := getGNMIServiceVersion()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &gnmi.CapabilityResponse{
+		SupportedModels:    a.model.modelData,
+		SupportedEncodings: supportedEncodings,
+		GNMIVersion:        *ver,
+	}, nil
+}
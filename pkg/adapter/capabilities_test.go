@@ -27,15 +27,16 @@ import (
 )
 
 func TestCapabilities(t *testing.T) {
-	s, err := NewAdapter(NewModel(modeldata.ModelData, gostruct.SchemaTree["Device"]), nil)
+	m := NewModel(modeldata.ModelData, gostruct.SchemaTree["Device"])
+	s, err := NewAdapter(m, nil)
 	assert.NoError(t, err, "error in creating server: %v", err)
 
 	resp, err := s.Capabilities(context.Background(), &gnmi.CapabilityRequest{})
 	if err != nil {
 		t.Fatalf("got error %v, want nil", err)
 	}
-	if !reflect.DeepEqual(resp.GetSupportedModels(), model.modelData) {
-		t.Errorf("got supported models %v\nare not the same as\nmodel supported by the server %v", resp.GetSupportedModels(), model.modelData)
+	if !reflect.DeepEqual(resp.GetSupportedModels(), m.modelData) {
+		t.Errorf("got supported models %v\nare not the same as\nmodel supported by the server %v", resp.GetSupportedModels(), m.modelData)
 	}
 	if !reflect.DeepEqual(resp.GetSupportedEncodings(), supportedEncodings) {
 		t.Errorf("got supported encodings %v\nare not the same as\nencodings supported by the server %v", resp.GetSupportedEncodings(), supportedEncodings)
@@ -0,0 +1,71 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package creds supplies the identity a NETCONF session is dialed with, decoupling session dialing
+// from how that identity is obtained. Providers range from a fixed username/password to ones that
+// fetch short-lived credentials from an executable, a file, or a URL, mirroring the external-account
+// credential-source pattern used to feed Vault/cloud-metadata/CI-secret-store secrets into an
+// STS-style exchange.
+package creds
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Credentials identifies a NETCONF peer. Exactly one of Password or PrivateKey is normally set.
+// HostKeyCallback, when non-nil, verifies the server's host key; providers that have no opinion on
+// host verification leave it nil and let the caller supply its own (see NewSessionWithCredentials).
+type Credentials struct {
+	Username        string
+	Password        string
+	PrivateKey      []byte // PEM-encoded
+	HostKeyCallback ssh.HostKeyCallback
+
+	// Expiry is when these Credentials stop being valid; the zero value means they do not expire.
+	// Provider is re-invoked before Expiry by anything dialing a long-lived session against it.
+	Expiry time.Time
+}
+
+// Expired reports whether c's Expiry has passed, as of now. Credentials with a zero Expiry never
+// expire.
+func (c Credentials) Expired(now time.Time) bool {
+	return !c.Expiry.IsZero() && !now.Before(c.Expiry)
+}
+
+// AuthMethods returns the ssh.AuthMethods implied by c: a password method if Password is set, a
+// public key method if PrivateKey is set, or both if both are set.
+func (c Credentials) AuthMethods() ([]ssh.AuthMethod, error) {
+	var methods []ssh.AuthMethod
+	if c.Password != "" {
+		methods = append(methods, ssh.Password(c.Password))
+	}
+	if len(c.PrivateKey) > 0 {
+		signer, err := ssh.ParsePrivateKey(c.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, ssh.PublicKeys(signer))
+	}
+	return methods, nil
+}
+
+// Provider supplies Credentials for dialing a NETCONF device. It is re-invoked every time a session
+// is (re)dialed - including reconnects after a transport failure - so that short-lived credentials
+// stay fresh without the adapter needing to know how they are refreshed.
+type Provider interface {
+	Get(ctx context.Context) (Credentials, error)
+}
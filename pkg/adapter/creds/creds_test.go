@@ -0,0 +1,71 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package creds
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestCredentialsExpired(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.False(t, Credentials{}.Expired(now), "zero Expiry never expires")
+	assert.False(t, Credentials{Expiry: now.Add(time.Minute)}.Expired(now), "future expiry is not yet expired")
+	assert.True(t, Credentials{Expiry: now.Add(-time.Minute)}.Expired(now), "past expiry is expired")
+	assert.True(t, Credentials{Expiry: now}.Expired(now), "expiry at now is expired")
+}
+
+func TestStaticProvider(t *testing.T) {
+	p := NewStaticProvider("alice", "s3cret")
+	got, err := p.Get(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, Credentials{Username: "alice", Password: "s3cret"}, got)
+}
+
+func TestParseCredentialDocument(t *testing.T) {
+	doc := []byte(`{"username":"alice","password":"s3cret","expiry":"2020-06-01T12:00:00Z"}`)
+	got, err := parseCredentialDocument(doc)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", got.Username)
+	assert.Equal(t, "s3cret", got.Password)
+	assert.Equal(t, time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC), got.Expiry)
+}
+
+func TestParseCredentialDocumentNoExpiry(t *testing.T) {
+	got, err := parseCredentialDocument([]byte(`{"username":"alice","password":"s3cret"}`))
+	assert.NoError(t, err)
+	assert.True(t, got.Expiry.IsZero())
+}
+
+func TestParseCredentialDocumentInvalidExpiry(t *testing.T) {
+	_, err := parseCredentialDocument([]byte(`{"username":"alice","expiry":"not-a-timestamp"}`))
+	assert.Error(t, err)
+}
+
+func TestExecProviderCachesUntilExpiry(t *testing.T) {
+	p := NewExecProvider("sh", "-c", `echo '{"username":"alice","password":"s3cret","expiry":"2999-01-01T00:00:00Z"}'`)
+
+	first, err := p.Get(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", first.Username)
+
+	p.command = "false" // would fail if invoked again
+	second, err := p.Get(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, first, second, "cached credentials should be reused without re-invoking the executable")
+}
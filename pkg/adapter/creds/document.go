@@ -0,0 +1,53 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package creds
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// credentialDocument is the JSON shape emitted by the exec, file and URL providers:
+//
+//	{"username": "...", "password": "...", "private_key": "...", "expiry": "2020-01-01T00:00:00Z"}
+//
+// private_key, when present, is PEM text; expiry, when present, is RFC 3339 and omitted for
+// credentials that do not expire.
+type credentialDocument struct {
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+	PrivateKey string `json:"private_key"`
+	Expiry     string `json:"expiry"`
+}
+
+// parseCredentialDocument parses raw as a credentialDocument and converts it to Credentials.
+func parseCredentialDocument(raw []byte) (Credentials, error) {
+	var doc credentialDocument
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return Credentials{}, errors.Wrap(err, "failed to parse credential document")
+	}
+
+	creds := Credentials{Username: doc.Username, Password: doc.Password, PrivateKey: []byte(doc.PrivateKey)}
+	if doc.Expiry != "" {
+		expiry, err := time.Parse(time.RFC3339, doc.Expiry)
+		if err != nil {
+			return Credentials{}, errors.Wrapf(err, "failed to parse credential expiry %q", doc.Expiry)
+		}
+		creds.Expiry = expiry
+	}
+	return creds, nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package creds
+
+import (
+	"context"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// expiryMargin is how long before a cached credential's Expiry ExecProvider and URLProvider treat
+// it as stale and refresh it early, so a dial started just before expiry doesn't race the device
+// rejecting it.
+const expiryMargin = 30 * time.Second
+
+// ExecProvider obtains Credentials by running a user-configured binary and reading a JSON
+// credential document (see credentialDocument) from its stdout, caching the result until shortly
+// before its reported expiry.
+type ExecProvider struct {
+	command string
+	args    []string
+
+	mu     sync.Mutex
+	cached Credentials
+	have   bool
+}
+
+// NewExecProvider returns a Provider that runs command with args to obtain credentials.
+func NewExecProvider(command string, args ...string) *ExecProvider {
+	return &ExecProvider{command: command, args: args}
+}
+
+// Get implements Provider.
+func (p *ExecProvider) Get(ctx context.Context) (Credentials, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.have && !p.cached.Expired(time.Now().Add(expiryMargin)) {
+		return p.cached, nil
+	}
+
+	out, err := exec.CommandContext(ctx, p.command, p.args...).Output()
+	if err != nil {
+		return Credentials{}, errors.Wrapf(err, "credential executable %s failed", p.command)
+	}
+	creds, err := parseCredentialDocument(out)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	p.cached, p.have = creds, true
+	return creds, nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package creds
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+	log "k8s.io/klog"
+)
+
+// FileProvider reads a JSON credential document (see credentialDocument) from a file, reloading it
+// whenever the file changes so that a secret rotated on disk - e.g. by Vault agent or a sidecar - is
+// picked up without restarting the adapter.
+type FileProvider struct {
+	path    string
+	current atomic.Value // Credentials
+	watcher *fsnotify.Watcher
+}
+
+// NewFileProvider loads the credential document at path and starts watching its directory for
+// changes, reloading on every write. Callers should Close the returned FileProvider once it is no
+// longer needed, to stop the watcher goroutine.
+func NewFileProvider(path string) (*FileProvider, error) {
+	p := &FileProvider{path: path}
+	if err := p.reload(); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create credentials file watcher")
+	}
+	// Watch the containing directory, not the file itself: editors and secret-rotation sidecars
+	// commonly replace a file rather than writing it in place, which re-points the path at a new
+	// inode fsnotify would otherwise lose its watch on.
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		_ = watcher.Close()
+		return nil, errors.Wrapf(err, "failed to watch directory of credentials file %s", path)
+	}
+	p.watcher = watcher
+	go p.watch()
+	return p, nil
+}
+
+func (p *FileProvider) watch() {
+	target := filepath.Clean(p.path)
+	for {
+		select {
+		case event, ok := <-p.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != target || event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+			if err := p.reload(); err != nil {
+				log.Errorf("failed to reload credentials file %s: %v", p.path, err)
+			}
+		case err, ok := <-p.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Errorf("credentials file watcher error for %s: %v", p.path, err)
+		}
+	}
+}
+
+func (p *FileProvider) reload() error {
+	raw, err := ioutil.ReadFile(p.path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read credentials file %s", p.path)
+	}
+	creds, err := parseCredentialDocument(raw)
+	if err != nil {
+		return err
+	}
+	p.current.Store(creds)
+	return nil
+}
+
+// Get implements Provider, returning the most recently loaded credential document.
+func (p *FileProvider) Get(ctx context.Context) (Credentials, error) {
+	creds, _ := p.current.Load().(Credentials)
+	return creds, nil
+}
+
+// Close stops watching the credentials file for changes.
+func (p *FileProvider) Close() error {
+	if p.watcher == nil {
+		return nil
+	}
+	return p.watcher.Close()
+}
@@ -0,0 +1,53 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package creds
+
+import (
+	"context"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// KeyFileProvider supplies an SSH private key loaded from disk, verifying the device's host key
+// against a known_hosts file rather than accepting it unconditionally. The key and known_hosts
+// files are read once, at construction.
+type KeyFileProvider struct {
+	username        string
+	privateKey      []byte
+	hostKeyCallback ssh.HostKeyCallback
+}
+
+// NewKeyFileProvider loads the private key at keyPath and the known_hosts file at knownHostsPath,
+// returning a Provider that authenticates as username with the key and verifies the device against
+// known_hosts.
+func NewKeyFileProvider(username, keyPath, knownHostsPath string) (*KeyFileProvider, error) {
+	key, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read SSH private key %s", keyPath)
+	}
+	callback, err := knownhosts.New(knownHostsPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load known_hosts file %s", knownHostsPath)
+	}
+	return &KeyFileProvider{username: username, privateKey: key, hostKeyCallback: callback}, nil
+}
+
+// Get implements Provider.
+func (p *KeyFileProvider) Get(ctx context.Context) (Credentials, error) {
+	return Credentials{Username: p.username, PrivateKey: p.privateKey, HostKeyCallback: p.hostKeyCallback}, nil
+}
@@ -0,0 +1,34 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package creds
+
+import "context"
+
+// StaticProvider supplies a fixed username/password pair, configured once at startup. It never
+// expires and ignores ctx.
+type StaticProvider struct {
+	Username string
+	Password string
+}
+
+// NewStaticProvider returns a Provider that always returns the given username/password.
+func NewStaticProvider(username, password string) *StaticProvider {
+	return &StaticProvider{Username: username, Password: password}
+}
+
+// Get implements Provider.
+func (p *StaticProvider) Get(ctx context.Context) (Credentials, error) {
+	return Credentials{Username: p.Username, Password: p.Password}, nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package creds
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// URLProvider obtains Credentials by GETing a JSON credential document (see credentialDocument)
+// from a URL, authenticating the request with a bearer token read from an environment variable so
+// the token itself is never part of the adapter's configuration. The document is cached until
+// shortly before its reported expiry.
+type URLProvider struct {
+	url         string
+	tokenEnvVar string
+	client      *http.Client
+
+	mu     sync.Mutex
+	cached Credentials
+	have   bool
+}
+
+// NewURLProvider returns a Provider that fetches credentials from url, sending the value of the
+// tokenEnvVar environment variable as a bearer token.
+func NewURLProvider(url, tokenEnvVar string) *URLProvider {
+	return &URLProvider{url: url, tokenEnvVar: tokenEnvVar, client: http.DefaultClient}
+}
+
+// Get implements Provider.
+func (p *URLProvider) Get(ctx context.Context) (Credentials, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.have && !p.cached.Expired(time.Now().Add(expiryMargin)) {
+		return p.cached, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, p.url, nil)
+	if err != nil {
+		return Credentials{}, errors.Wrapf(err, "failed to build credential request for %s", p.url)
+	}
+	req = req.WithContext(ctx)
+	if token := os.Getenv(p.tokenEnvVar); token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return Credentials{}, errors.Wrapf(err, "failed to fetch credentials from %s", p.url)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Credentials{}, fmt.Errorf("credential endpoint %s returned %s", p.url, resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return Credentials{}, errors.Wrapf(err, "failed to read credential response from %s", p.url)
+	}
+	creds, err := parseCredentialDocument(body)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	p.cached, p.have = creds, true
+	return creds, nil
+}
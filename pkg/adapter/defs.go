@@ -16,7 +16,13 @@
 package adapter
 
 import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
 	"github.com/damianoneill/net/v2/netconf/ops"
+	"github.com/onosproject/gnmi-netconf-adapter/pkg/adapter/audit"
 
 	gnmi "github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/openconfig/ygot/ygot"
@@ -26,7 +32,7 @@ import (
 type ConfigCallback func(ygot.ValidatedGoStruct) error
 
 var (
-	supportedEncodings = []gnmi.Encoding{gnmi.Encoding_JSON}
+	supportedEncodings = []gnmi.Encoding{gnmi.Encoding_JSON, gnmi.Encoding_JSON_IETF, gnmi.Encoding_PROTO, gnmi.Encoding_ASCII}
 )
 
 // Adapter struct implements the interface of gnmi server. It supports Capabilities, Get, and Set APIs.
@@ -42,4 +48,142 @@ var (
 type Adapter struct {
 	model *Model
 	ncs   ops.OpSession
+
+	// pool, when non-nil, supersedes ncs: sessions are acquired from and released back to it for
+	// each RPC instead of reusing a single long-lived session.
+	pool *sessionPool
+
+	// registry, when non-nil, supersedes both ncs and pool: the session for an RPC is resolved from
+	// the gNMI target the request addresses (see targetFor), allowing one Adapter to front multiple
+	// NETCONF devices.
+	registry *DeviceRegistry
+
+	// resolver, when non-nil, supersedes ncs, pool, and registry: both the Model and the session for
+	// a Get RPC are resolved from the gNMI target it addresses, allowing one Adapter to front devices
+	// that run different schemas. See NewAdapterWithTargetResolver.
+	resolver TargetResolver
+
+	// subMgr backs Subscribe STREAM requests; it is created lazily on first use.
+	subMgr     *subscriptionManager
+	subMgrOnce sync.Once
+
+	// audit, when non-nil, receives one audit.Event per Get/Set/Subscribe RPC the Adapter serves;
+	// see WithAuditEmitter.
+	audit audit.Emitter
+
+	// confirmedCommitTimeout overrides the default timeout Set passes to the candidate datastore's
+	// confirmed commit on devices that support a candidate+confirmed-commit transaction; see
+	// SetConfirmedCommitTimeout.
+	confirmedCommitTimeout time.Duration
+}
+
+// AdapterOption configures optional behaviour on an Adapter, applied by NewAdapter.
+type AdapterOption func(*Adapter)
+
+// WithAuditEmitter configures the Adapter to publish an audit.Event to e for every Get, Set, and
+// Subscribe RPC it serves. Without this option, RPCs are not audited.
+func WithAuditEmitter(e audit.Emitter) AdapterOption {
+	return func(a *Adapter) {
+		a.audit = e
+	}
+}
+
+// NewAdapterWithDeviceRegistry returns an Adapter that dispatches each RPC against the NETCONF
+// device named by the request's gNMI target (gnmi.Path.Target, falling back to
+// gnmi.Prefix.Target), resolved through registry. Every target shares the Adapter's configured
+// Model; see DeviceRegistry's doc comment for that limitation.
+func NewAdapterWithDeviceRegistry(m *Model, registry *DeviceRegistry) (gnmi.GNMIServer, error) {
+	return &Adapter{model: m, registry: registry}, nil
+}
+
+// ReloadInventory replaces the device set of an Adapter built with NewAdapterWithDeviceRegistry or
+// NewAdapterWithTargetResolver from the inventory file at path (see DeviceRegistry.LoadInventoryFile
+// and StaticTargetResolver.LoadInventoryFile). It returns an error if the Adapter was not built with
+// either.
+func (a *Adapter) ReloadInventory(path string) error {
+	if a.registry != nil {
+		return a.registry.LoadInventoryFile(path)
+	}
+	if reloadable, ok := a.resolver.(interface{ LoadInventoryFile(path string) error }); ok {
+		return reloadable.LoadInventoryFile(path)
+	}
+	return fmt.Errorf("adapter was not configured with a device registry or target resolver")
+}
+
+// NewAdapterWithTargetResolver returns an Adapter that dispatches each Get RPC against the
+// (Model, NETCONF session) pair resolved for the gNMI target it addresses (gnmi.Path.Target,
+// falling back to gnmi.Prefix.Target), resolved through resolver. Unlike
+// NewAdapterWithDeviceRegistry, different targets may run different schemas. A request with no
+// target is resolved against the empty-string target; resolver should either bind that to a default
+// device or fail Lookup with codes.InvalidArgument.
+func NewAdapterWithTargetResolver(resolver TargetResolver, opts ...AdapterOption) (gnmi.GNMIServer, error) {
+	a := &Adapter{resolver: resolver}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a, nil
+}
+
+// NewAdapterWithSessionPool returns an Adapter that dispatches each RPC against a session drawn
+// from a pool of up to maxSessions concurrently-dialed NETCONF sessions, rather than a single
+// session shared (and serialised) across every RPC.
+func NewAdapterWithSessionPool(m *Model, dial SessionFactory, maxSessions int) (gnmi.GNMIServer, error) {
+	return &Adapter{model: m, pool: newSessionPool(dial, maxSessions)}, nil
+}
+
+// acquireSession returns the NETCONF session this RPC should use for the given gNMI target: one
+// resolved through the device registry if the Adapter was built with one, one drawn from the pool if
+// the Adapter was built with one, or the single long-lived session otherwise. target is the empty
+// string for Adapters that do not front multiple devices.
+func (a *Adapter) acquireSession(ctx context.Context, target string) (ops.OpSession, error) {
+	if a.registry != nil {
+		return a.registry.Get(ctx, target)
+	}
+	if a.pool == nil {
+		return a.ncs, nil
+	}
+	return a.pool.Get(ctx)
+}
+
+// releaseSession returns a session acquired via acquireSession for target. failed should be true
+// when the caller observed a transport error on the session, so the pool can discard and replace it
+// instead of handing it back out.
+func (a *Adapter) releaseSession(target string, s ops.OpSession, failed bool) {
+	if a.registry != nil {
+		a.registry.Release(target, s, failed)
+		return
+	}
+	if a.pool == nil {
+		return
+	}
+	if failed {
+		a.pool.Discard(s)
+	} else {
+		a.pool.Put(s)
+	}
+}
+
+// resolveTarget returns the Model and NETCONF session that should serve target: the DeviceBinding
+// resolved through a's TargetResolver when one is configured, or a's single configured Model paired
+// with acquireSession's result otherwise.
+func (a *Adapter) resolveTarget(ctx context.Context, target string) (*Model, ops.OpSession, error) {
+	if a.resolver != nil {
+		binding, err := a.resolver.Lookup(ctx, target)
+		if err != nil {
+			return nil, nil, err
+		}
+		return binding.Model, binding.Session, nil
+	}
+	ncs, err := a.acquireSession(ctx, target)
+	return a.model, ncs, err
+}
+
+// releaseTarget returns a (Model, session) pair acquired via resolveTarget for target. failed should
+// be true when the caller observed a transport error on the session.
+func (a *Adapter) releaseTarget(target string, m *Model, s ops.OpSession, failed bool) {
+	if a.resolver != nil {
+		a.resolver.Release(target, &DeviceBinding{Model: m, Session: s}, failed)
+		return
+	}
+	a.releaseSession(target, s, failed)
 }
@@ -0,0 +1,175 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package adapter implements a gnmi server that adapts to a netconf device.
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/damianoneill/net/v2/netconf/ops"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/yaml.v2"
+)
+
+// DeviceConfig describes a single NETCONF device entry in a DeviceRegistry inventory file.
+type DeviceConfig struct {
+	Target   string `yaml:"target" json:"target"`
+	Address  string `yaml:"address" json:"address"`
+	Username string `yaml:"username" json:"username"`
+	Password string `yaml:"password" json:"password"`
+}
+
+// DeviceDialer opens a NETCONF session for a device described by cfg. It is supplied by the caller
+// of NewDeviceRegistry so that the adapter package stays independent of the transport used to reach
+// a device's NETCONF endpoint (e.g. SSH host key policy, per-site bastions).
+type DeviceDialer func(ctx context.Context, cfg DeviceConfig) (ops.OpSession, error)
+
+// device is a DeviceConfig plus a session pool dialed lazily against it on first use.
+type device struct {
+	cfg  DeviceConfig
+	pool *sessionPool
+}
+
+// DeviceRegistry holds a session pool per gNMI target, so that a single Adapter can front an
+// arbitrary set of NETCONF devices selected by gnmi.Path.Target (or gnmi.Path.Prefix.Target) on a
+// per-RPC basis. Sessions are dialed lazily the first time a target is addressed. The device set can
+// be replaced at runtime via LoadInventoryFile, e.g. in response to SIGHUP, without interrupting
+// sessions for devices that are unchanged across the reload.
+//
+// Schema selection is unchanged by this type: every target served by a DeviceRegistry-backed Adapter
+// shares the Adapter's configured Model. Adapters that need per-device schema selection should use a
+// ModelRegistry (see NewAdapterFromRegistry) instead.
+type DeviceRegistry struct {
+	dial        DeviceDialer
+	maxSessions int
+
+	mu      sync.RWMutex
+	devices map[string]*device
+}
+
+// NewDeviceRegistry creates an empty DeviceRegistry. dial opens a NETCONF session for a device the
+// first time its target is addressed; maxSessions bounds the number of concurrently-dialed sessions
+// kept open per device.
+func NewDeviceRegistry(dial DeviceDialer, maxSessions int) *DeviceRegistry {
+	return &DeviceRegistry{dial: dial, maxSessions: maxSessions, devices: make(map[string]*device)}
+}
+
+// LoadInventoryFile replaces the registry's device set with the contents of the inventory file at
+// path, parsed as JSON if its extension is ".json" and as YAML otherwise (YAML is a superset of
+// JSON, so ".yaml"/".yml" files as well as any other extension are accepted as YAML). Devices that
+// are unchanged keep their existing (and possibly already-dialed) session pool; devices that are
+// removed have their pool's sessions closed.
+func (r *DeviceRegistry) LoadInventoryFile(path string) error {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read device inventory %s", path)
+	}
+	var cfgs []DeviceConfig
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(raw, &cfgs)
+	} else {
+		err = yaml.Unmarshal(raw, &cfgs)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse device inventory %s", path)
+	}
+	r.reload(cfgs)
+	return nil
+}
+
+// reload swaps in the device set described by cfgs, reusing the pool of any device whose
+// configuration is unchanged and discarding the pools of devices no longer present.
+func (r *DeviceRegistry) reload(cfgs []DeviceConfig) {
+	next := make(map[string]*device, len(cfgs))
+
+	r.mu.Lock()
+	for _, cfg := range cfgs {
+		if existing, ok := r.devices[cfg.Target]; ok && existing.cfg == cfg {
+			next[cfg.Target] = existing
+			continue
+		}
+		d := &device{cfg: cfg}
+		d.pool = newSessionPool(func(ctx context.Context) (ops.OpSession, error) {
+			return r.dial(ctx, d.cfg)
+		}, r.maxSessions)
+		next[cfg.Target] = d
+	}
+	removed := make([]*device, 0, len(r.devices))
+	for target, d := range r.devices {
+		if next[target] != d {
+			removed = append(removed, d)
+		}
+	}
+	r.devices = next
+	r.mu.Unlock()
+
+	for _, d := range removed {
+		d.pool.Close()
+	}
+}
+
+// Get returns a NETCONF session for target, dialing it lazily on first use.
+func (r *DeviceRegistry) Get(ctx context.Context, target string) (ops.OpSession, error) {
+	d, ok := r.lookup(target)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown gNMI target %q", target)
+	}
+	return d.pool.Get(ctx)
+}
+
+// Release returns a session acquired via Get to target's pool. failed should be true when the caller
+// observed a transport error on the session, so the pool discards and redials it instead of handing
+// it back out.
+func (r *DeviceRegistry) Release(target string, s ops.OpSession, failed bool) {
+	d, ok := r.lookup(target)
+	if !ok {
+		return
+	}
+	if failed {
+		d.pool.Discard(s)
+	} else {
+		d.pool.Put(s)
+	}
+}
+
+// Healthy reports whether target is known and its session pool currently has a usable session.
+func (r *DeviceRegistry) Healthy(target string) bool {
+	d, ok := r.lookup(target)
+	return ok && d.pool.Healthy()
+}
+
+func (r *DeviceRegistry) lookup(target string) (*device, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	d, ok := r.devices[target]
+	return d, ok
+}
+
+// targetFor resolves the gNMI target a request addresses, preferring the path's own Target and
+// falling back to the prefix's, as described in the gNMI specification's treatment of Path.target.
+func targetFor(prefix, path *gnmi.Path) string {
+	if t := path.GetTarget(); t != "" {
+		return t
+	}
+	return prefix.GetTarget()
+}
@@ -0,0 +1,84 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package adapter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/damianoneill/net/v2/netconf/ops"
+	"github.com/damianoneill/net/v2/netconf/ops/mocks"
+	assert "github.com/stretchr/testify/require"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// fakeRegistry builds a DeviceRegistry for device1/device2 that dials the supplied mock sessions,
+// for use by tests that need Set/Get to be routed to a specific fake NETCONF session by target.
+func fakeRegistry(t *testing.T, sessions map[string]ops.OpSession) *DeviceRegistry {
+	r := NewDeviceRegistry(func(ctx context.Context, cfg DeviceConfig) (ops.OpSession, error) {
+		s, ok := sessions[cfg.Target]
+		if !ok {
+			t.Fatalf("unexpected dial for target %q", cfg.Target)
+		}
+		return s, nil
+	}, 1)
+	cfgs := make([]DeviceConfig, 0, len(sessions))
+	for target := range sessions {
+		cfgs = append(cfgs, DeviceConfig{Target: target})
+	}
+	r.reload(cfgs)
+	return r
+}
+
+func TestSetRoutesByTarget(t *testing.T) {
+	device1 := &mocks.OpSession{}
+	device1.On("EditConfigCfg", ops.RunningCfg, `<configuration operation="replace"><version>ABC</version></configuration>`).Return(nil)
+	device2 := &mocks.OpSession{}
+	device2.On("EditConfigCfg", ops.RunningCfg, `<configuration operation="replace"><version>ABC</version></configuration>`).Return(nil)
+
+	registry := fakeRegistry(t, map[string]ops.OpSession{"device1": device1, "device2": device2})
+	s, err := NewAdapterWithDeviceRegistry(model, registry)
+	assert.NoError(t, err, "error in creating server: %v", err)
+
+	var path gnmi.Path
+	assert.NoError(t, proto.UnmarshalText(`elem: <name: "version" >`, &path))
+	req := &gnmi.SetRequest{
+		Prefix:  &gnmi.Path{Target: "device2", Elem: []*gnmi.PathElem{{Name: "configuration"}}},
+		Replace: []*gnmi.Update{{Path: &path, Val: &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: "ABC"}}}},
+	}
+
+	_, err = s.Set(context.TODO(), req)
+	assert.NoError(t, err)
+
+	device2.AssertExpectations(t)
+	device1.AssertNotCalled(t, "EditConfigCfg", ops.RunningCfg, `<configuration operation="replace"><version>ABC</version></configuration>`)
+}
+
+func TestSetUnknownTarget(t *testing.T) {
+	registry := fakeRegistry(t, map[string]ops.OpSession{"device1": &mocks.OpSession{}})
+	s, err := NewAdapterWithDeviceRegistry(model, registry)
+	assert.NoError(t, err, "error in creating server: %v", err)
+
+	var path gnmi.Path
+	assert.NoError(t, proto.UnmarshalText(`elem: <name: "version" >`, &path))
+	req := &gnmi.SetRequest{
+		Prefix:  &gnmi.Path{Target: "unknown-device", Elem: []*gnmi.PathElem{{Name: "configuration"}}},
+		Replace: []*gnmi.Update{{Path: &path, Val: &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: "ABC"}}}},
+	}
+
+	_, err = s.Set(context.TODO(), req)
+	assert.Error(t, err)
+}
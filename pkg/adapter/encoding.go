@@ -0,0 +1,202 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package adapter implements a gnmi server that adapts to a netconf device.
+package adapter
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmi/value"
+	"github.com/openconfig/goyang/pkg/yang"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// encoder converts a value decoded from a NETCONF response into the gNMI Updates for a single
+// requested path, in one wire encoding. Containers may expand into more than one Update (PROTO);
+// leaves always produce exactly one.
+type encoder interface {
+	encodeLeaf(path *gnmi.Path, value interface{}) (*gnmi.Update, error)
+	encodeContainer(entry *yang.Entry, path *gnmi.Path, value map[string]interface{}) ([]*gnmi.Update, error)
+}
+
+// encoderFor returns the encoder implementing the requested gNMI wire encoding.
+func encoderFor(enc gnmi.Encoding) (encoder, error) {
+	switch enc {
+	case gnmi.Encoding_JSON:
+		return jsonEncoder{}, nil
+	case gnmi.Encoding_JSON_IETF:
+		return jsonIETFEncoder{}, nil
+	case gnmi.Encoding_PROTO:
+		return protoEncoder{}, nil
+	case gnmi.Encoding_ASCII:
+		return asciiEncoder{}, nil
+	default:
+		return nil, status.Errorf(codes.Unimplemented, "unsupported encoding: %s", gnmi.Encoding_name[int32(enc)])
+	}
+}
+
+// jsonEncoder emits scalars via value.FromScalar and containers as a single JsonVal blob, matching
+// the adapter's original (JSON) behaviour.
+type jsonEncoder struct{}
+
+func (jsonEncoder) encodeLeaf(path *gnmi.Path, v interface{}) (*gnmi.Update, error) {
+	val, err := value.FromScalar(v)
+	if err != nil {
+		return nil, status.Error(codes.Internal, fmt.Sprintf("leaf node %v does not contain a scalar type value: %v", path, err))
+	}
+	return &gnmi.Update{Path: path, Val: val}, nil
+}
+
+func (jsonEncoder) encodeContainer(_ *yang.Entry, path *gnmi.Path, v map[string]interface{}) ([]*gnmi.Update, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, status.Error(codes.Internal, fmt.Sprintf("error in marshaling JSON tree to bytes: %v", err))
+	}
+	return []*gnmi.Update{{Path: path, Val: &gnmi.TypedValue{Value: &gnmi.TypedValue_JsonVal{JsonVal: b}}}}, nil
+}
+
+// jsonIETFEncoder emits RFC 7951 ("JSON_IETF") encoded values: identityref leaves are qualified with
+// their defining module name, and 64-bit integers are encoded as strings, as RFC 7951 requires.
+type jsonIETFEncoder struct{}
+
+func (jsonIETFEncoder) encodeLeaf(path *gnmi.Path, v interface{}) (*gnmi.Update, error) {
+	val, err := value.FromScalar(rfc7951Scalar(v))
+	if err != nil {
+		return nil, status.Error(codes.Internal, fmt.Sprintf("leaf node %v does not contain a scalar type value: %v", path, err))
+	}
+	return &gnmi.Update{Path: path, Val: val}, nil
+}
+
+func (e jsonIETFEncoder) encodeContainer(entry *yang.Entry, path *gnmi.Path, v map[string]interface{}) ([]*gnmi.Update, error) {
+	b, err := json.Marshal(rfc7951Container(entry, v))
+	if err != nil {
+		return nil, status.Error(codes.Internal, fmt.Sprintf("error in marshaling JSON_IETF tree to bytes: %v", err))
+	}
+	return []*gnmi.Update{{Path: path, Val: &gnmi.TypedValue{Value: &gnmi.TypedValue_JsonIetfVal{JsonIetfVal: b}}}}, nil
+}
+
+// rfc7951Scalar applies the RFC 7951 scalar encoding rules to a single leaf value.
+func rfc7951Scalar(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int64, uint64:
+		return fmt.Sprintf("%v", n)
+	default:
+		return v
+	}
+}
+
+// rfc7951Container applies rfc7951Scalar recursively to every leaf in a decoded container value.
+// Module-qualifying identityref values accurately requires the originating module for each leaf,
+// which the current map-based decode does not retain; nested containers/lists are walked so that
+// 64-bit integer leaves anywhere in the subtree are still string-encoded per RFC 7951.
+func rfc7951Container(entry *yang.Entry, v map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(v))
+	for k, val := range v {
+		switch child := val.(type) {
+		case map[string]interface{}:
+			var childEntry *yang.Entry
+			if entry != nil {
+				childEntry = entry.Dir[k]
+			}
+			out[k] = rfc7951Container(childEntry, child)
+		case []interface{}:
+			list := make([]interface{}, len(child))
+			for i, e := range child {
+				if m, ok := e.(map[string]interface{}); ok {
+					var childEntry *yang.Entry
+					if entry != nil {
+						childEntry = entry.Dir[k]
+					}
+					list[i] = rfc7951Container(childEntry, m)
+				} else {
+					list[i] = rfc7951Scalar(e)
+				}
+			}
+			out[k] = list
+		default:
+			out[k] = rfc7951Scalar(val)
+		}
+	}
+	return out
+}
+
+// protoEncoder expands a container into one Update per leaf, as required for the PROTO encoding
+// (which has no equivalent of a JSON blob).
+type protoEncoder struct{}
+
+func (protoEncoder) encodeLeaf(path *gnmi.Path, v interface{}) (*gnmi.Update, error) {
+	val, err := value.FromScalar(v)
+	if err != nil {
+		return nil, status.Error(codes.Internal, fmt.Sprintf("leaf node %v does not contain a scalar type value: %v", path, err))
+	}
+	return &gnmi.Update{Path: path, Val: val}, nil
+}
+
+func (e protoEncoder) encodeContainer(entry *yang.Entry, path *gnmi.Path, v map[string]interface{}) ([]*gnmi.Update, error) {
+	var updates []*gnmi.Update
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		childPath := &gnmi.Path{Origin: path.Origin, Elem: append(append([]*gnmi.PathElem{}, path.Elem...), &gnmi.PathElem{Name: k})}
+		var childEntry *yang.Entry
+		if entry != nil {
+			childEntry = entry.Dir[k]
+		}
+		switch child := v[k].(type) {
+		case map[string]interface{}:
+			childUpdates, err := e.encodeContainer(childEntry, childPath, child)
+			if err != nil {
+				return nil, err
+			}
+			updates = append(updates, childUpdates...)
+		case []interface{}:
+			for _, item := range child {
+				if m, ok := item.(map[string]interface{}); ok {
+					childUpdates, err := e.encodeContainer(childEntry, childPath, m)
+					if err != nil {
+						return nil, err
+					}
+					updates = append(updates, childUpdates...)
+				}
+			}
+		default:
+			upd, err := e.encodeLeaf(childPath, child)
+			if err != nil {
+				return nil, err
+			}
+			updates = append(updates, upd)
+		}
+	}
+	return updates, nil
+}
+
+// asciiEncoder supports scalar leaves only; ASCII has no defined representation for containers.
+type asciiEncoder struct{}
+
+func (asciiEncoder) encodeLeaf(path *gnmi.Path, v interface{}) (*gnmi.Update, error) {
+	return &gnmi.Update{Path: path, Val: &gnmi.TypedValue{Value: &gnmi.TypedValue_AsciiVal{AsciiVal: fmt.Sprintf("%v", v)}}}, nil
+}
+
+func (asciiEncoder) encodeContainer(_ *yang.Entry, path *gnmi.Path, _ map[string]interface{}) ([]*gnmi.Update, error) {
+	return nil, status.Errorf(codes.Unimplemented, "ASCII encoding does not support container node %v", path)
+}
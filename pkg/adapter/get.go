@@ -17,30 +17,32 @@ package adapter
 
 import (
 	"bytes"
-	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"reflect"
-	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
-	"github.com/openconfig/gnmi/value"
-
 	"github.com/openconfig/goyang/pkg/yang"
 
 	"github.com/damianoneill/net/v2/netconf/ops"
 
+	"github.com/onosproject/gnmi-netconf-adapter/pkg/adapter/audit"
+	"github.com/onosproject/gnmi-netconf-adapter/pkg/adapter/telemetry"
 	"github.com/openconfig/gnmi/proto/gnmi"
+	"go.opentelemetry.io/otel/api/trace"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
-	log "k8s.io/klog"
 )
 
 // Get implements the Get RPC in gNMI spec.
-func (a *Adapter) Get(ctx context.Context, req *gnmi.GetRequest) (*gnmi.GetResponse, error) {
+func (a *Adapter) Get(ctx context.Context, req *gnmi.GetRequest) (resp *gnmi.GetResponse, err error) {
+
+	start := time.Now()
+	ctx, span := telemetry.Tracer().Start(ctx, "adapter.Get")
+	defer span.End()
+	defer func() { a.emitGetEvent(ctx, req, start, err) }()
 
 	if err := a.checkEncodingAndModel(req.GetEncoding(), req.GetUseModels()); err != nil {
 		return nil, status.Error(codes.Unimplemented, err.Error())
@@ -66,9 +68,30 @@ func (a *Adapter) Get(ctx context.Context, req *gnmi.GetRequest) (*gnmi.GetRespo
 	return &gnmi.GetResponse{Notification: notifications}, nil
 }
 
+// emitGetEvent publishes an audit.GetEvent for a completed Get RPC, if the Adapter was configured
+// with WithAuditEmitter.
+func (a *Adapter) emitGetEvent(ctx context.Context, req *gnmi.GetRequest, start time.Time, err error) {
+	if a.audit == nil {
+		return
+	}
+	paths := make([]string, len(req.GetPath()))
+	for i, p := range req.GetPath() {
+		paths[i] = p.String()
+	}
+	a.audit.EmitGetEvent(ctx, audit.GetEvent{
+		Identity: audit.IdentityFromContext(ctx),
+		Paths:    paths,
+		Code:     status.Code(err).String(),
+		Duration: time.Since(start),
+	})
+}
+
 // Exexcutes a gNMI Get for a single path
 func (a *Adapter) processPath(ctx context.Context, req *gnmi.GetRequest, path *gnmi.Path) (*gnmi.Notification, error) {
 
+	ctx, span := telemetry.Tracer().Start(ctx, "adapter.processPath", trace.WithAttributes(telemetry.KeyGNMIPath.String(path.String())))
+	defer span.End()
+
 	// Resolve the full path using the prefix if there is one.
 	prefix := req.GetPrefix()
 	fullPath := path
@@ -76,20 +99,28 @@ func (a *Adapter) processPath(ctx context.Context, req *gnmi.GetRequest, path *g
 		fullPath = gnmiFullPath(prefix, path)
 	}
 
+	target := targetFor(prefix, path)
+	m, ncs, err := a.resolveTarget(ctx, target)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "no NETCONF session available: %v", err)
+	}
+
 	// Check that the requested path is defined in the schema
-	entry := a.getSchemaEntryForPath(fullPath)
+	entry := getSchemaEntryForPath(m, fullPath)
 	if entry == nil {
+		a.releaseTarget(target, m, ncs, false)
 		return nil, status.Errorf(codes.NotFound, "path %v not found (Test)", fullPath)
 	}
 
 	// Convert the request path to a netconf subtree filter and execute a get-config.
-	netconfTree, err := a.executeGetConfig(pathToNetconfSubtree(fullPath), fullPath)
+	netconfTree, err := a.executeGetConfig(ctx, ncs, target, pathToNetconfSubtree(fullPath), fullPath)
+	a.releaseTarget(target, m, ncs, err != nil)
 	if err != nil {
 		return nil, err
 	}
 
 	// Convert the netconf response to a gNMI notification
-	return a.netconfValueToGnmi(entry, netconfTree, fullPath, prefix)
+	return a.netconfValueToGnmi(ctx, m, entry, netconfTree, fullPath, prefix, req.GetEncoding())
 }
 
 // pathToNetconfSubtree converts a gNMI path to an XML string holding an equivalent netconf subtree filter.
@@ -118,19 +149,26 @@ func pathToNetconfSubtree(path *gnmi.Path) interface{} {
 	return buf.String()
 }
 
-// executeGetConfig issues a netconfig get-config request using the specified subtree filter, returning the
-// response as an XML string.
-func (a *Adapter) executeGetConfig(filter interface{}, path *gnmi.Path) (string, error) {
+// executeGetConfig issues a netconfig get-config request on ncs using the specified subtree filter,
+// returning the response as an XML string.
+func (a *Adapter) executeGetConfig(ctx context.Context, ncs ops.OpSession, target string, filter interface{}, path *gnmi.Path) (string, error) {
+	_, span := telemetry.Tracer().Start(ctx, "adapter.executeGetConfig", trace.WithAttributes(
+		telemetry.KeyNetconfDatastore.String("running"),
+		telemetry.KeyNetconfOperation.String("get-config"),
+		telemetry.KeyDeviceTarget.String(target),
+	))
+	defer span.End()
+
 	result := ""
-	err := a.ncs.GetConfigSubtree(filter, ops.RunningCfg, &result)
-	if err != nil {
+	if err := ncs.GetConfigSubtree(filter, ops.RunningCfg, &result); err != nil {
 		return "", status.Errorf(codes.Unknown, "failed to get config for %v %v", path, err)
 	}
+	span.SetAttributes(telemetry.KeyResponseBytes.Int(len(result)))
 	return result, nil
 }
 
-// netconfValueToGnmi converts the netconf XML response to a gNMI notification.
-func (a *Adapter) netconfValueToGnmi(entry *yang.Entry, result string, path *gnmi.Path, prefix *gnmi.Path) (*gnmi.Notification, error) {
+// netconfValueToGnmi converts the netconf XML response to a gNMI notification, decoding it against m.
+func (a *Adapter) netconfValueToGnmi(ctx context.Context, m *Model, entry *yang.Entry, result string, path *gnmi.Path, prefix *gnmi.Path, enc gnmi.Encoding) (*gnmi.Notification, error) {
 
 	// The conversion is a 3-step process:
 	// 1 - transform the netconf XML to a regular map, using the schema to create slices for lists and to convert
@@ -141,13 +179,16 @@ func (a *Adapter) netconfValueToGnmi(entry *yang.Entry, result string, path *gnm
 	// Note that the first two steps could be merged into a single operation, so that the netconf to transformation only
 	// took place for the requested node.
 
-	netconfMap := a.netconfXMLtoMap(result)
+	netconfMap, err := a.netconfXMLtoMap(ctx, m, result)
+	if err != nil {
+		return nil, err
+	}
 
 	requestedValue, err := getRequestedNode(netconfMap, path)
 	if err != nil {
 		return nil, err
 	}
-	return a.buildGnmiNotification(entry, requestedValue, path, prefix)
+	return a.buildGnmiNotification(ctx, entry, requestedValue, path, prefix, enc)
 }
 
 // eldesc is used to track the state of XML element decoding.
@@ -170,16 +211,19 @@ type eldesc struct {
 // - nested maps for container values
 // - arrays of scalars/maps for leaf/container lists
 // If netconf elements are not defined in the schema, they are not included in the map.
-func (a *Adapter) netconfXMLtoMap(result string) map[string]interface{} {
+func (a *Adapter) netconfXMLtoMap(ctx context.Context, m *Model, result string) (map[string]interface{}, error) {
+	_, span := telemetry.Tracer().Start(ctx, "adapter.netconfXMLtoMap")
+	defer span.End()
+
 	dec := xml.NewDecoder(strings.NewReader(result))
 
 	top := make(map[string]interface{})
-	cureld := &eldesc{schema: a.model.schemaTreeRoot, children: top}
+	cureld := &eldesc{schema: m.schemaTreeRoot, children: top}
 
 	for {
 		tk, _ := dec.Token()
 		if tk == nil {
-			return top
+			return top, nil
 		}
 
 		switch v := tk.(type) {
@@ -205,7 +249,9 @@ func (a *Adapter) netconfXMLtoMap(result string) map[string]interface{} {
 		case xml.CharData:
 			// Only interested in the character data for an element that corresponds to a leaf/leaf-list.
 			if cureld.schema != nil && (cureld.schema.IsLeaf() || cureld.schema.IsLeafList()) {
-				addLeafValueToParent(v, cureld)
+				if err := addLeafValueToParent(v, cureld); err != nil {
+					return nil, err
+				}
 			}
 
 		case xml.ProcInst:
@@ -217,14 +263,18 @@ func (a *Adapter) netconfXMLtoMap(result string) map[string]interface{} {
 }
 
 // addLeafValueToParent adds a leaf value to the parent container's map.
-func addLeafValueToParent(input xml.CharData, cureld *eldesc) {
-	value := getLeafValue(input, cureld.schema)
+func addLeafValueToParent(input xml.CharData, cureld *eldesc) error {
+	value, err := getLeafValue(input, cureld.schema)
+	if err != nil {
+		return err
+	}
 	tag := cureld.schema.Name
 	if cureld.schema.IsLeaf() {
 		cureld.parent.children[tag] = value
 	} else {
 		cureld.parent.children[tag] = append(cureld.parent.children[tag].([]interface{}), value)
 	}
+	return nil
 }
 
 // linkNodeToParent links a container/leaf to its parent node.
@@ -269,38 +319,55 @@ func getRequestedNode(input interface{}, path *gnmi.Path) (interface{}, error) {
 }
 
 // buildGnmiNotification maps the netconf returned value to a gNMI notification
-func (a *Adapter) buildGnmiNotification(entry *yang.Entry, requestedValue interface{}, path *gnmi.Path, prefix *gnmi.Path) (*gnmi.Notification, error) {
+func (a *Adapter) buildGnmiNotification(ctx context.Context, entry *yang.Entry, requestedValue interface{}, path *gnmi.Path, prefix *gnmi.Path, enc gnmi.Encoding) (*gnmi.Notification, error) {
+
+	_, span := telemetry.Tracer().Start(ctx, "adapter.buildGnmiNotification")
+	defer span.End()
+
+	enCoder, err := encoderFor(enc)
+	if err != nil {
+		return nil, err
+	}
 
 	if entry.IsLeaf() {
-		val, err := value.FromScalar(reflect.ValueOf(&requestedValue).Elem().Interface())
+		update, err := enCoder.encodeLeaf(path, reflect.ValueOf(&requestedValue).Elem().Interface())
 		if err != nil {
-			return nil, status.Error(codes.Internal, fmt.Sprintf("leaf node %v does not contain a scalar type value: %v", path, err))
+			return nil, err
 		}
-		return notification(prefix, &gnmi.Update{Path: path, Val: val}), nil
+		return notification(prefix, update), nil
 	}
 	if entry.IsDir() {
-		jsonDump, err := json.Marshal(requestedValue)
+		container, ok := requestedValue.(map[string]interface{})
+		if !ok {
+			return nil, status.Error(codes.Internal, fmt.Sprintf("container node %v did not decode to a map", path))
+		}
+		updates, err := enCoder.encodeContainer(entry, path, container)
 		if err != nil {
-			return nil, status.Error(codes.Internal, fmt.Sprintf("error in marshaling %s JSON tree to bytes: %v", "Internal", err))
+			return nil, err
 		}
-		return notification(prefix, &gnmi.Update{Path: path, Val: &gnmi.TypedValue{Value: &gnmi.TypedValue_JsonVal{JsonVal: jsonDump}}}), nil
+		return notifications(prefix, updates), nil
 	}
 	panic(fmt.Sprintf("unexpected schema entry type %s", entry.Name))
 }
 
 // notification returns a new Notification with the specified prefix, update and the current time.
 func notification(prefix *gnmi.Path, update *gnmi.Update) *gnmi.Notification {
+	return notifications(prefix, []*gnmi.Update{update})
+}
+
+// notifications returns a new Notification with the specified prefix, updates and the current time.
+func notifications(prefix *gnmi.Path, updates []*gnmi.Update) *gnmi.Notification {
 	return &gnmi.Notification{
 		Timestamp: time.Now().UnixNano(),
 		Prefix:    prefix,
-		Update:    []*gnmi.Update{update},
+		Update:    updates,
 	}
 }
 
-// getSchemaEntryForPath delivers the schema entry associated with the last element of the supplied path,
-// returning nil if the schema does not include the path.
-func (a *Adapter) getSchemaEntryForPath(path *gnmi.Path) *yang.Entry {
-	entry := a.model.schemaTreeRoot
+// getSchemaEntryForPath delivers the schema entry associated with the last element of the supplied
+// path against m, returning nil if the schema does not include the path.
+func getSchemaEntryForPath(m *Model, path *gnmi.Path) *yang.Entry {
+	entry := m.schemaTreeRoot
 	for _, elem := range path.Elem {
 		entry = entry.Dir[elem.Name]
 		if entry == nil {
@@ -314,136 +381,3 @@ func getChildSchema(name string, parent *yang.Entry) *yang.Entry {
 	return parent.Dir[name]
 }
 
-// Delivers the value of leaf, using the type defined by the associated schema entry.
-func getLeafValue(v xml.CharData, schema *yang.Entry) interface{} {
-
-	switch schema.Type.Kind {
-	case yang.Ystring:
-		return strings.TrimSpace(string(v))
-	case yang.Yunion:
-		val, _ := getUnionValue(strings.TrimSpace(string(v)), schema.Type.Type)
-		return val
-	case yang.Yuint32:
-		val, _ := strconv.ParseUint(strings.TrimSpace(string(v)), 10, 64)
-		return val
-	case yang.Yenum:
-		return strings.TrimSpace(string(v))
-	}
-	// TODO Handle other kinds
-	log.Errorf("Leaf kind %s still to be supported", schema.Type.Kind)
-	return strings.TrimSpace(string(v))
-}
-
-func getUnionValue(v string, types []*yang.YangType) (interface{}, error) {
-	for _, t := range types {
-		switch t.Kind {
-		case yang.Ystring:
-			if isValidString(v, t) {
-				return v, nil
-			}
-		case yang.Yint32:
-			val := isValidInt(v, t)
-			if val != nil {
-				return val, nil
-			}
-		}
-		// TODO Add other kinds.
-	}
-	return nil, status.Errorf(codes.NotFound, "failed to set union value: %s", v)
-}
-
-func isValidString(v string, t *yang.YangType) bool {
-	return anyPatternMatches(v, t.Pattern)
-	// TODO Range checks?
-}
-
-func isValidInt(v string, t *yang.YangType) interface{} {
-	val, err := strconv.ParseInt(v, 10, 32)
-	if err != nil {
-		return nil
-	}
-
-	for _, r := range t.Range {
-		if val >= int64(r.Min.Value) && val <= int64(r.Max.Value) {
-			return val
-		}
-	}
-
-	return nil
-}
-
-func anyPatternMatches(v string, patterns []string) bool {
-	for _, p := range patterns {
-		if !patternMatches(v, p) {
-			return false
-		}
-	}
-	return true
-}
-
-func patternMatches(v string, p string) bool {
-	// fixYangRegexp adds ^(...)$ around the pattern - the result is
-	// equivalent to a full match of whole string.
-	r, err := regexp.Compile(fixYangRegexp(p))
-	return err != nil && r.MatchString(v)
-}
-
-// Following function is lifted unchanged from https://github.com/openconfig/ygot/blob/master/ytypes/string_type.go
-
-// fixYangRegexp takes a pattern regular expression from a YANG module and
-// returns it into a format which can be used by the Go regular expression
-// library. YANG uses a W3C standard that is defined to be implicitly anchored
-// at the head or tail of the expression. See
-// https://www.w3.org/TR/2004/REC-xmlschema-2-20041028/#regexs for details.
-func fixYangRegexp(pattern string) string {
-	var buf bytes.Buffer
-	var inEscape bool
-	var prevChar rune
-	addParens := false
-
-	for i, ch := range pattern {
-		if i == 0 && ch != '^' {
-			buf.WriteRune('^')
-			// Add parens around entire expression to prevent logical
-			// subexpressions associating with leading/trailing ^ / $.
-			buf.WriteRune('(')
-			addParens = true
-		}
-
-		switch ch {
-		case '$':
-			// Dollar signs need to be escaped unless they are at
-			// the end of the pattern, or are already escaped.
-			if !inEscape && i != len(pattern)-1 {
-				buf.WriteRune('\\')
-			}
-		case '^':
-			// Carets need to be escaped unless they are already
-			// escaped, indicating set negation ([^.*]) or at the
-			// start of the string.
-			if !inEscape && prevChar != '[' && i != 0 {
-				buf.WriteRune('\\')
-			}
-		}
-
-		// If the previous character was an escape character, then we
-		// leave the escape, otherwise check whether this is an escape
-		// char and if so, then enter escape.
-		inEscape = !inEscape && ch == '\\'
-
-		buf.WriteRune(ch)
-
-		if i == len(pattern)-1 {
-			if addParens {
-				buf.WriteRune(')')
-			}
-			if ch != '$' {
-				buf.WriteRune('$')
-			}
-		}
-
-		prevChar = ch
-	}
-
-	return buf.String()
-}
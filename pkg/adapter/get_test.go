@@ -34,10 +34,21 @@ import (
 
 	"github.com/openconfig/gnmi/proto/gnmi"
 
+	"github.com/onosproject/gnmi-netconf-adapter/pkg/adapter/audit"
 	"github.com/onosproject/gnmi-netconf-adapter/pkg/adapter/modeldata"
 	"github.com/onosproject/gnmi-netconf-adapter/pkg/adapter/modeldata/gostruct"
 )
 
+// recordingEmitter is an audit.Emitter that keeps every event it receives, so tests can assert on
+// what was audited without standing up a real backend.
+type recordingEmitter struct {
+	getEvents []audit.GetEvent
+}
+
+func (r *recordingEmitter) EmitGetEvent(ctx context.Context, e audit.GetEvent)             { r.getEvents = append(r.getEvents, e) }
+func (r *recordingEmitter) EmitSetEvent(ctx context.Context, e audit.SetEvent)             {}
+func (r *recordingEmitter) EmitSubscribeEvent(ctx context.Context, e audit.SubscribeEvent) {}
+
 type getTestCase struct {
 	nilPath     bool
 	desc        string
@@ -251,7 +262,8 @@ func runTestGet(t *testing.T, tc *getTestCase) {
 		})
 
 	model = NewModel(modeldata.ModelData, gostruct.SchemaTree["Device"])
-	s, err := NewAdapter(model, mockNc)
+	rec := &recordingEmitter{}
+	s, err := NewAdapter(model, mockNc, WithAuditEmitter(rec))
 	assert.NoError(t, err, "error in creating server: %v", err)
 
 	pbPaths := []*gnmi.Path{}
@@ -278,6 +290,10 @@ func runTestGet(t *testing.T, tc *getTestCase) {
 	assert.True(t, ok, "got a non-grpc error from grpc call")
 	assert.Equal(t, tc.wantRetCode, gotRetStatus.Code(), "Unexpected return code")
 
+	// Every call should produce exactly one audit record, reporting the same return code.
+	assert.Len(t, rec.getEvents, 1, "expected exactly one audit record for the Get call")
+	assert.Equal(t, tc.wantRetCode.String(), rec.getEvents[0].Code)
+
 	// Check response value
 	var gotVal interface{}
 	if resp != nil {
@@ -312,3 +328,63 @@ func runTestGet(t *testing.T, tc *getTestCase) {
 		t.Errorf("got: %v (%T),\nwant %v (%T)", gotVal, gotVal, tc.wantRespVal, tc.wantRespVal)
 	}
 }
+
+// TestEncoding round-trips a leaf and a container value through each non-JSON encoder, against the
+// same Junos-derived schema fixture used by TestGet, checking both encoderFor's dispatch and the
+// wire representation each encoding produces.
+func TestEncoding(t *testing.T) {
+	model = NewModel(modeldata.ModelData, gostruct.SchemaTree["Device"])
+
+	leafPath := &gnmi.Path{Elem: []*gnmi.PathElem{
+		{Name: "configuration"}, {Name: "system"}, {Name: "services"}, {Name: "ssh"}, {Name: "max-sessions-per-connection"},
+	}}
+	containerPath := &gnmi.Path{Elem: []*gnmi.PathElem{
+		{Name: "configuration"}, {Name: "system"}, {Name: "services"}, {Name: "ssh"},
+	}}
+	containerEntry := getSchemaEntryForPath(model, containerPath)
+	containerVal := map[string]interface{}{"max-sessions-per-connection": int64(32)}
+
+	t.Run("JSON_IETF", func(t *testing.T) {
+		e, err := encoderFor(gnmi.Encoding_JSON_IETF)
+		assert.NoError(t, err)
+
+		upd, err := e.encodeLeaf(leafPath, int64(32))
+		assert.NoError(t, err)
+		assert.Equal(t, "32", upd.GetVal().GetStringVal())
+
+		updates, err := e.encodeContainer(containerEntry, containerPath, containerVal)
+		assert.NoError(t, err)
+		assert.Len(t, updates, 1, "expected a single JSON_IETF blob for the container")
+		var got map[string]interface{}
+		assert.NoError(t, json.Unmarshal(updates[0].GetVal().GetJsonIetfVal(), &got))
+		assert.Equal(t, map[string]interface{}{"max-sessions-per-connection": "32"}, got)
+	})
+
+	t.Run("PROTO", func(t *testing.T) {
+		e, err := encoderFor(gnmi.Encoding_PROTO)
+		assert.NoError(t, err)
+
+		upd, err := e.encodeLeaf(leafPath, int64(32))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(32), upd.GetVal().GetIntVal())
+
+		updates, err := e.encodeContainer(containerEntry, containerPath, containerVal)
+		assert.NoError(t, err)
+		assert.Len(t, updates, 1, "expected one Update per leaf under the container")
+		assert.Equal(t, "max-sessions-per-connection", updates[0].GetPath().GetElem()[len(updates[0].GetPath().GetElem())-1].GetName())
+		assert.Equal(t, int64(32), updates[0].GetVal().GetIntVal())
+	})
+
+	t.Run("ASCII", func(t *testing.T) {
+		e, err := encoderFor(gnmi.Encoding_ASCII)
+		assert.NoError(t, err)
+
+		upd, err := e.encodeLeaf(leafPath, int64(32))
+		assert.NoError(t, err)
+		assert.Equal(t, "32", upd.GetVal().GetAsciiVal())
+
+		_, err = e.encodeContainer(containerEntry, containerPath, containerVal)
+		assert.Error(t, err, "ASCII encoding has no representation for containers")
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+}
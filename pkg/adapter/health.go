@@ -0,0 +1,53 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package adapter implements a gnmi server that adapts to a netconf device.
+package adapter
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// HealthServer returns the Adapter itself as a grpc_health_v1.HealthServer, reporting SERVING only
+// while its NETCONF session pool has a session available (idle, or spare dial capacity). Adapters
+// built around a single long-lived session (i.e. without a pool) always report SERVING.
+func (a *Adapter) HealthServer() grpc_health_v1.HealthServer {
+	return a
+}
+
+// Check implements grpc_health_v1.HealthServer. For an Adapter backed by a DeviceRegistry, req's
+// Service field is interpreted as the gNMI target to check; an empty Service reports on the Adapter
+// as a whole.
+func (a *Adapter) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	if a.registry != nil {
+		if req.GetService() != "" && !a.registry.Healthy(req.GetService()) {
+			return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_NOT_SERVING}, nil
+		}
+		return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+	}
+	if a.pool != nil && !a.pool.Healthy() {
+		return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_NOT_SERVING}, nil
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+// Watch implements grpc_health_v1.HealthServer. Streaming health updates are not supported; clients
+// should poll Check instead.
+func (a *Adapter) Watch(req *grpc_health_v1.HealthCheckRequest, stream grpc_health_v1.Health_WatchServer) error {
+	return status.Error(codes.Unimplemented, "streaming health checks are not supported, use Check")
+}
@@ -0,0 +1,304 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package adapter implements a gnmi server that adapts to a netconf device.
+package adapter
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// getLeafValue delivers the value of a leaf, using the type defined by the associated schema entry,
+// enforcing any YANG range/length constraints the type declares.
+func getLeafValue(v xml.CharData, schema *yang.Entry) (interface{}, error) {
+	s := strings.TrimSpace(string(v))
+	return scalarValue(s, schema.Type)
+}
+
+// scalarValue converts s to the Go value for the given YANG type, applying range/length/pattern
+// constraints and returning status.Error(codes.InvalidArgument, ...) on violation.
+func scalarValue(s string, t *yang.YangType) (interface{}, error) {
+	switch t.Kind {
+	case yang.Ystring:
+		if err := checkStringConstraints(s, t); err != nil {
+			return nil, err
+		}
+		return s, nil
+	case yang.Yunion:
+		return getUnionValue(s, t.Type)
+	case yang.Yenum:
+		return s, nil
+	case yang.Ybool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid boolean value %q: %v", s, err)
+		}
+		return b, nil
+	case yang.Yempty:
+		return true, nil
+	case yang.Yint8:
+		return parseIntKind(s, t, 8)
+	case yang.Yint16:
+		return parseIntKind(s, t, 16)
+	case yang.Yint32:
+		return parseIntKind(s, t, 32)
+	case yang.Yint64:
+		return parseIntKind(s, t, 64)
+	case yang.Yuint8:
+		return parseUintKind(s, t, 8)
+	case yang.Yuint16:
+		return parseUintKind(s, t, 16)
+	case yang.Yuint32:
+		return parseUintKind(s, t, 32)
+	case yang.Yuint64:
+		return parseUintKind(s, t, 64)
+	case yang.Ydecimal64:
+		return parseDecimal64(s, t)
+	case yang.Ybinary:
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid base64 binary value %q: %v", s, err)
+		}
+		return b, nil
+	case yang.Ybits:
+		return strings.Fields(s), nil
+	case yang.Yidentityref:
+		return resolveIdentityref(s, t), nil
+	case yang.Yleafref:
+		if target := resolveLeafrefType(t); target != nil {
+			return scalarValue(s, target)
+		}
+		return s, nil
+	}
+	return nil, status.Errorf(codes.Internal, "unsupported YANG leaf kind %s for value %q", t.Kind, s)
+}
+
+// getUnionValue resolves a union value against each of its member types in turn, returning the
+// first type that accepts the value.
+func getUnionValue(v string, types []*yang.YangType) (interface{}, error) {
+	for _, t := range types {
+		if val, err := scalarValue(v, t); err == nil {
+			return val, nil
+		}
+	}
+	return nil, status.Errorf(codes.InvalidArgument, "value %q does not match any member type of the union", v)
+}
+
+// parseIntKind parses a signed integer of the given bit size, enforcing the type's range
+// restrictions (if any).
+func parseIntKind(s string, t *yang.YangType, bitSize int) (interface{}, error) {
+	val, err := strconv.ParseInt(s, 10, bitSize)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid %s value %q: %v", t.Kind, s, err)
+	}
+	if !inInt64Range(val, t.Range) {
+		return nil, status.Errorf(codes.InvalidArgument, "%s value %d is out of range for type %s", t.Kind, val, t.Name)
+	}
+	return val, nil
+}
+
+// parseUintKind parses an unsigned integer of the given bit size, enforcing the type's range
+// restrictions (if any).
+func parseUintKind(s string, t *yang.YangType, bitSize int) (interface{}, error) {
+	val, err := strconv.ParseUint(s, 10, bitSize)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid %s value %q: %v", t.Kind, s, err)
+	}
+	if !inUint64Range(val, t.Range) {
+		return nil, status.Errorf(codes.InvalidArgument, "%s value %d is out of range for type %s", t.Kind, val, t.Name)
+	}
+	return val, nil
+}
+
+// parseDecimal64 parses a YANG decimal64 value, rejecting values with more fraction digits than
+// the type's fraction-digits substatement allows.
+func parseDecimal64(s string, t *yang.YangType) (interface{}, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid decimal64 value %q: %v", s, err)
+	}
+	if dot := strings.IndexByte(s, '.'); dot >= 0 {
+		if fracDigits := len(s) - dot - 1; fracDigits > int(t.FractionDigits) {
+			return nil, status.Errorf(codes.InvalidArgument, "decimal64 value %q has more than %d fraction digits", s, t.FractionDigits)
+		}
+	}
+	return f, nil
+}
+
+// resolveIdentityref qualifies an identityref value with the prefix of the module that actually
+// defines it, looked up by local name in t's identity base hierarchy (identity values are resolved
+// globally by name against their base, not by whatever XML namespace prefix the wire value happened
+// to use). If t carries no identity base, or the local name is not found anywhere in its hierarchy,
+// s is returned unchanged.
+func resolveIdentityref(s string, t *yang.YangType) string {
+	name := s
+	if i := strings.IndexByte(s, ':'); i >= 0 {
+		name = s[i+1:]
+	}
+	if t.IdentityBase == nil {
+		return s
+	}
+	if identity := findIdentity(t.IdentityBase, name); identity != nil {
+		return identity.PrefixedName()
+	}
+	return s
+}
+
+// findIdentity searches base and its derived identities (recursively) for the one named name,
+// returning nil if none matches.
+func findIdentity(base *yang.Identity, name string) *yang.Identity {
+	if base.Name == name {
+		return base
+	}
+	for _, derived := range base.Values {
+		if found := findIdentity(derived, name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// resolveLeafrefType returns the YangType that a leafref's path statement resolves to, or nil if it
+// cannot be determined from the schema alone (e.g. the target uses a relative XPath expression that
+// has not been resolved into Type by goyang).
+func resolveLeafrefType(t *yang.YangType) *yang.YangType {
+	if len(t.Type) != 1 {
+		return nil
+	}
+	return t.Type[0]
+}
+
+// inInt64Range reports whether val satisfies any of the given YANG range restrictions, or true if
+// there are none.
+func inInt64Range(val int64, ranges []yang.YRange) bool {
+	if len(ranges) == 0 {
+		return true
+	}
+	for _, r := range ranges {
+		if val >= int64(r.Min.Value) && val <= int64(r.Max.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// inUint64Range reports whether val satisfies any of the given YANG range restrictions, or true if
+// there are none.
+func inUint64Range(val uint64, ranges []yang.YRange) bool {
+	if len(ranges) == 0 {
+		return true
+	}
+	for _, r := range ranges {
+		if val >= r.Min.Value && val <= r.Max.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// checkStringConstraints enforces a string type's pattern and length restrictions.
+func checkStringConstraints(v string, t *yang.YangType) error {
+	if !anyPatternMatches(v, t.Pattern) {
+		return status.Errorf(codes.InvalidArgument, "value %q does not match any pattern restriction of type %s", v, t.Name)
+	}
+	if len(t.Length) > 0 && !inUint64Range(uint64(len(v)), t.Length) {
+		return status.Errorf(codes.InvalidArgument, "value %q violates the length restriction of type %s", v, t.Name)
+	}
+	return nil
+}
+
+func anyPatternMatches(v string, patterns []string) bool {
+	for _, p := range patterns {
+		if !patternMatches(v, p) {
+			return false
+		}
+	}
+	return true
+}
+
+func patternMatches(v string, p string) bool {
+	// fixYangRegexp adds ^(...)$ around the pattern - the result is
+	// equivalent to a full match of whole string.
+	r, err := regexp.Compile(fixYangRegexp(p))
+	return err == nil && r.MatchString(v)
+}
+
+// Following function is lifted unchanged from https://github.com/openconfig/ygot/blob/master/ytypes/string_type.go
+
+// fixYangRegexp takes a pattern regular expression from a YANG module and
+// returns it into a format which can be used by the Go regular expression
+// library. YANG uses a W3C standard that is defined to be implicitly anchored
+// at the head or tail of the expression. See
+// https://www.w3.org/TR/2004/REC-xmlschema-2-20041028/#regexs for details.
+func fixYangRegexp(pattern string) string {
+	var buf bytes.Buffer
+	var inEscape bool
+	var prevChar rune
+	addParens := false
+
+	for i, ch := range pattern {
+		if i == 0 && ch != '^' {
+			buf.WriteRune('^')
+			// Add parens around entire expression to prevent logical
+			// subexpressions associating with leading/trailing ^ / $.
+			buf.WriteRune('(')
+			addParens = true
+		}
+
+		switch ch {
+		case '$':
+			// Dollar signs need to be escaped unless they are at
+			// the end of the pattern, or are already escaped.
+			if !inEscape && i != len(pattern)-1 {
+				buf.WriteRune('\\')
+			}
+		case '^':
+			// Carets need to be escaped unless they are already
+			// escaped, indicating set negation ([^.*]) or at the
+			// start of the string.
+			if !inEscape && prevChar != '[' && i != 0 {
+				buf.WriteRune('\\')
+			}
+		}
+
+		// If the previous character was an escape character, then we
+		// leave the escape, otherwise check whether this is an escape
+		// char and if so, then enter escape.
+		inEscape = !inEscape && ch == '\\'
+
+		buf.WriteRune(ch)
+
+		if i == len(pattern)-1 {
+			if addParens {
+				buf.WriteRune(')')
+			}
+			if ch != '$' {
+				buf.WriteRune('$')
+			}
+		}
+
+		prevChar = ch
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,136 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"testing"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	assert "github.com/stretchr/testify/require"
+)
+
+func yrange(min, max uint64) []yang.YRange {
+	return []yang.YRange{{Min: yang.Number{Value: min}, Max: yang.Number{Value: max}}}
+}
+
+func TestScalarValue(t *testing.T) {
+	tests := []struct {
+		desc      string
+		in        string
+		typ       *yang.YangType
+		want      interface{}
+		wantError bool
+	}{
+		{desc: "string", in: "hello", typ: &yang.YangType{Kind: yang.Ystring}, want: "hello"},
+		{desc: "bool true", in: "true", typ: &yang.YangType{Kind: yang.Ybool}, want: true},
+		{desc: "bool invalid", in: "yes", typ: &yang.YangType{Kind: yang.Ybool}, wantError: true},
+		{desc: "empty", in: "", typ: &yang.YangType{Kind: yang.Yempty}, want: true},
+		{desc: "int8 in range", in: "12", typ: &yang.YangType{Kind: yang.Yint8, Range: yrange(0, 100)}, want: int64(12)},
+		{desc: "int8 out of range", in: "120", typ: &yang.YangType{Kind: yang.Yint8, Range: yrange(0, 100)}, wantError: true},
+		{desc: "uint16", in: "512", typ: &yang.YangType{Kind: yang.Yuint16}, want: uint64(512)},
+		{desc: "uint32", in: "4000000000", typ: &yang.YangType{Kind: yang.Yuint32}, want: uint64(4000000000)},
+		{desc: "int64", in: "-9000000000", typ: &yang.YangType{Kind: yang.Yint64}, want: int64(-9000000000)},
+		{desc: "decimal64", in: "3.50", typ: &yang.YangType{Kind: yang.Ydecimal64, FractionDigits: 2}, want: 3.50},
+		{desc: "decimal64 too many fraction digits", in: "3.5001", typ: &yang.YangType{Kind: yang.Ydecimal64, FractionDigits: 2}, wantError: true},
+		{desc: "binary", in: "aGVsbG8=", typ: &yang.YangType{Kind: yang.Ybinary}, want: []byte("hello")},
+		{desc: "binary invalid", in: "not base64!!", typ: &yang.YangType{Kind: yang.Ybinary}, wantError: true},
+		{desc: "bits", in: "a b c", typ: &yang.YangType{Kind: yang.Ybits}, want: []string{"a", "b", "c"}},
+		{desc: "identityref without an identity base passes through unchanged", in: "iana-if-type:ethernetCsmacd", typ: &yang.YangType{Kind: yang.Yidentityref}, want: "iana-if-type:ethernetCsmacd"},
+		{
+			desc: "string length violation",
+			in:   "toolong",
+			typ:  &yang.YangType{Kind: yang.Ystring, Length: yrange(0, 3)},
+			wantError: true,
+		},
+		{
+			desc: "union resolves to matching member",
+			in:   "42",
+			typ:  &yang.YangType{Kind: yang.Yunion, Type: []*yang.YangType{{Kind: yang.Ystring, Pattern: []string{"[a-z]+"}}, {Kind: yang.Yint32}}},
+			want: int64(42),
+		},
+		{
+			desc:      "union matches no member",
+			in:        "42",
+			typ:       &yang.YangType{Kind: yang.Yunion, Type: []*yang.YangType{{Kind: yang.Ystring, Pattern: []string{"[a-z]+"}}}},
+			wantError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := scalarValue(tc.in, tc.typ)
+			if tc.wantError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+// newIdentity builds a yang.Identity named name, defined in its own module prefixed modulePrefix,
+// with the given (already-constructed) derived identities recorded as its Values - mirroring how
+// goyang resolves an identity hierarchy: Values cross-references a base to its derived identities
+// without changing their own defining module.
+func newIdentity(modulePrefix, name string, values ...*yang.Identity) *yang.Identity {
+	module := &yang.Module{Prefix: &yang.Value{Name: modulePrefix}}
+	return &yang.Identity{Name: name, Values: values, Parent: module}
+}
+
+func TestResolveIdentityref(t *testing.T) {
+	ethernetCsmacd := newIdentity("iana-if-type", "ethernetCsmacd")
+	base := newIdentity("ietf-interfaces", "interface-type", ethernetCsmacd)
+
+	tests := []struct {
+		desc string
+		in   string
+		base *yang.Identity
+		want string
+	}{
+		{
+			desc: "resolves to the defining module's prefix regardless of the wire prefix",
+			in:   "bogus-prefix:ethernetCsmacd",
+			base: base,
+			want: "iana-if-type:ethernetCsmacd",
+		},
+		{
+			desc: "resolves an unprefixed value the same way",
+			in:   "ethernetCsmacd",
+			base: base,
+			want: "iana-if-type:ethernetCsmacd",
+		},
+		{
+			desc: "unknown identity name passes through unchanged",
+			in:   "iana-if-type:noSuchType",
+			base: base,
+			want: "iana-if-type:noSuchType",
+		},
+		{
+			desc: "no identity base passes through unchanged",
+			in:   "iana-if-type:ethernetCsmacd",
+			base: nil,
+			want: "iana-if-type:ethernetCsmacd",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			got := resolveIdentityref(tc.in, &yang.YangType{Kind: yang.Yidentityref, IdentityBase: tc.base})
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
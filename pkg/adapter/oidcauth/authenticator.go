@@ -0,0 +1,81 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidcauth
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Authenticator verifies bearer tokens against one or more configured OIDC issuers.
+type Authenticator struct {
+	verifiers []*verifier
+}
+
+// NewAuthenticator builds an Authenticator that accepts tokens issued by any of issuers. A token is
+// accepted if it verifies against at least one of them; issuers are tried in order and the first
+// match wins.
+func NewAuthenticator(issuers []IssuerConfig) (*Authenticator, error) {
+	if len(issuers) == 0 {
+		return nil, errors.New("oidcauth: at least one issuer must be configured")
+	}
+	verifiers := make([]*verifier, len(issuers))
+	for i, issuer := range issuers {
+		verifiers[i] = newVerifier(issuer)
+	}
+	return &Authenticator{verifiers: verifiers}, nil
+}
+
+// Authenticate extracts the bearer token from ctx's incoming gRPC metadata and verifies it against
+// a's issuers, returning the resulting Identity.
+func (a *Authenticator) Authenticate(ctx context.Context) (*Identity, error) {
+	token, err := bearerToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for _, v := range a.verifiers {
+		identity, err := v.verify(token)
+		if err == nil {
+			return identity, nil
+		}
+		lastErr = err
+	}
+	return nil, status.Errorf(codes.Unauthenticated, "token rejected by all configured issuers: %v", lastErr)
+}
+
+// bearerToken extracts the token from a "Bearer <token>" authorization header in ctx's incoming gRPC
+// metadata.
+func bearerToken(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", status.Error(codes.Unauthenticated, "no metadata in context")
+	}
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return "", status.Error(codes.Unauthenticated, "no authorization header")
+	}
+	const prefix = "Bearer "
+	if !strings.HasPrefix(values[0], prefix) {
+		return "", status.Error(codes.Unauthenticated, "authorization header is not a bearer token")
+	}
+	return strings.TrimPrefix(values[0], prefix), nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidcauth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// jwksRefreshInterval bounds how long a fetched key set is trusted before it is re-fetched, so that
+// a key rotated at the issuer is picked up without requiring a process restart.
+const jwksRefreshInterval = 10 * time.Minute
+
+// jwk is the subset of RFC 7517 JSON Web Key fields this package uses. Only RSA keys (kty "RSA") are
+// supported, which matches every OIDC provider's default signing algorithm (RS256).
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type jwksDocument struct {
+	Keys []jwk `json:"keys"`
+}
+
+// keySet fetches and caches an issuer's JSON Web Key Set, re-fetching it at most once every
+// jwksRefreshInterval.
+type keySet struct {
+	jwksURL string
+	client  *http.Client
+
+	mu        sync.Mutex
+	fetchedAt time.Time
+	keys      map[string]*rsa.PublicKey
+}
+
+func newKeySet(jwksURL string) *keySet {
+	return &keySet{jwksURL: jwksURL, client: http.DefaultClient}
+}
+
+// key returns the RSA public key for kid, fetching (or re-fetching, if the cache has expired) the
+// key set from jwksURL as needed.
+func (k *keySet) key(kid string) (*rsa.PublicKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if key, ok := k.keys[kid]; ok && time.Since(k.fetchedAt) < jwksRefreshInterval {
+		return key, nil
+	}
+	if err := k.refreshLocked(); err != nil {
+		return nil, err
+	}
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, errors.Errorf("no key with kid %q in JWKS from %s", kid, k.jwksURL)
+	}
+	return key, nil
+}
+
+func (k *keySet) refreshLocked() error {
+	resp, err := k.client.Get(k.jwksURL)
+	if err != nil {
+		return errors.Wrapf(err, "failed to fetch JWKS from %s", k.jwksURL)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("fetching JWKS from %s: unexpected status %s", k.jwksURL, resp.Status)
+	}
+
+	var doc jwksDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return errors.Wrapf(err, "failed to decode JWKS from %s", k.jwksURL)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(doc.Keys))
+	for _, key := range doc.Keys {
+		if key.Kty != "RSA" {
+			continue
+		}
+		pub, err := rsaPublicKeyFromJWK(key)
+		if err != nil {
+			return errors.Wrapf(err, "invalid RSA key %q in JWKS from %s", key.Kid, k.jwksURL)
+		}
+		keys[key.Kid] = pub
+	}
+
+	k.keys = keys
+	k.fetchedAt = time.Now()
+	return nil
+}
+
+// rsaPublicKeyFromJWK decodes the base64url-encoded modulus and exponent of an RSA JWK into a usable
+// *rsa.PublicKey.
+func rsaPublicKeyFromJWK(key jwk) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(key.N)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding modulus")
+	}
+	e, err := base64.RawURLEncoding.DecodeString(key.E)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding exponent")
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
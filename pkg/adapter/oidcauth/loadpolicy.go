@@ -0,0 +1,40 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidcauth
+
+import (
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// LoadGroupPolicy reads and parses a GroupPolicy from the YAML file at path, a top-level list of
+// GroupRules, e.g.:
+//
+//   - groups: [netops]
+//     ops: [GET]
+//     paths: ["/configuration/interfaces/**"]
+func LoadGroupPolicy(path string) (*GroupPolicy, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read group policy %s", path)
+	}
+	var rules []GroupRule
+	if err := yaml.Unmarshal(raw, &rules); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse group policy %s", path)
+	}
+	return &GroupPolicy{Rules: rules}, nil
+}
@@ -0,0 +1,208 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package oidcauth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	assert "github.com/stretchr/testify/require"
+)
+
+const testIssuer = "https://issuer.example.com"
+
+// testIDP is an in-process stand-in for an OIDC issuer: it serves a JWKS over HTTP and mints tokens
+// signed with the key it advertises.
+type testIDP struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	kid    string
+}
+
+func newTestIDP(t *testing.T) *testIDP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	idp := &testIDP{key: key, kid: "test-key-1"}
+	idp.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(jwksDocument{Keys: []jwk{{
+			Kty: "RSA",
+			Kid: idp.kid,
+			Alg: "RS256",
+			N:   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+		}}})
+	}))
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (idp *testIDP) issuerConfig(audiences ...string) IssuerConfig {
+	return IssuerConfig{IssuerURL: testIssuer, JWKSURL: idp.server.URL, Audiences: audiences}
+}
+
+func (idp *testIDP) sign(t *testing.T, claims jwt.MapClaims, key *rsa.PrivateKey) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = idp.kid
+	raw, err := token.SignedString(key)
+	assert.NoError(t, err)
+	return raw
+}
+
+func (idp *testIDP) token(t *testing.T, subject string, groups []string, audience string) string {
+	return idp.sign(t, jwt.MapClaims{
+		"iss":    testIssuer,
+		"sub":    subject,
+		"aud":    audience,
+		"groups": groups,
+		"exp":    time.Now().Add(time.Hour).Unix(),
+	}, idp.key)
+}
+
+func TestVerifierRejectsBadSignature(t *testing.T) {
+	idp := newTestIDP(t)
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	raw := idp.sign(t, jwt.MapClaims{
+		"iss": testIssuer,
+		"sub": "alice",
+		"aud": "gnmi-adapter",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}, other)
+
+	v := newVerifier(idp.issuerConfig("gnmi-adapter"))
+	_, err = v.verify(raw)
+	assert.Error(t, err)
+}
+
+func TestVerifierRejectsAudienceMismatch(t *testing.T) {
+	idp := newTestIDP(t)
+	raw := idp.token(t, "alice", []string{"netops"}, "some-other-client")
+
+	v := newVerifier(idp.issuerConfig("gnmi-adapter"))
+	_, err := v.verify(raw)
+	assert.Error(t, err)
+}
+
+func TestVerifierRejectsAzpMismatch(t *testing.T) {
+	idp := newTestIDP(t)
+	raw := idp.sign(t, jwt.MapClaims{
+		"iss": testIssuer,
+		"sub": "alice",
+		"aud": "gnmi-adapter",
+		"azp": "some-other-client",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}, idp.key)
+
+	cfg := idp.issuerConfig("gnmi-adapter")
+	cfg.ClientID = "gnmi-adapter"
+	v := newVerifier(cfg)
+	_, err := v.verify(raw)
+	assert.Error(t, err)
+}
+
+func TestVerifierAcceptsClientIDInAzp(t *testing.T) {
+	idp := newTestIDP(t)
+	raw := idp.sign(t, jwt.MapClaims{
+		"iss": testIssuer,
+		"sub": "alice",
+		"aud": "some-other-audience",
+		"azp": "gnmi-adapter",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}, idp.key)
+
+	cfg := idp.issuerConfig()
+	cfg.ClientID = "gnmi-adapter"
+	v := newVerifier(cfg)
+	_, err := v.verify(raw)
+	assert.NoError(t, err)
+}
+
+func TestVerifierFallsBackToAudienceWithoutAzp(t *testing.T) {
+	idp := newTestIDP(t)
+	raw := idp.token(t, "alice", []string{"netops"}, "gnmi-adapter")
+
+	cfg := idp.issuerConfig()
+	cfg.ClientID = "gnmi-adapter"
+	v := newVerifier(cfg)
+	_, err := v.verify(raw)
+	assert.NoError(t, err)
+}
+
+func TestVerifierRejectsClientIDNotInAudienceWithoutAzp(t *testing.T) {
+	idp := newTestIDP(t)
+	raw := idp.token(t, "alice", []string{"netops"}, "some-other-client")
+
+	cfg := idp.issuerConfig()
+	cfg.ClientID = "gnmi-adapter"
+	v := newVerifier(cfg)
+	_, err := v.verify(raw)
+	assert.Error(t, err)
+}
+
+func TestVerifierAcceptsValidToken(t *testing.T) {
+	idp := newTestIDP(t)
+	raw := idp.token(t, "alice", []string{"netops"}, "gnmi-adapter")
+
+	v := newVerifier(idp.issuerConfig("gnmi-adapter"))
+	identity, err := v.verify(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", identity.Subject)
+	assert.Equal(t, []string{"netops"}, identity.Groups)
+}
+
+func testGroupPolicy() *GroupPolicy {
+	return &GroupPolicy{
+		Rules: []GroupRule{
+			{Groups: []string{"netops"}, Ops: []string{"GET"}, Paths: []string{"/configuration/interfaces/*"}},
+			{Groups: []string{"admins"}, Ops: []string{"GET", "SET"}, Paths: []string{"/configuration/**"}},
+		},
+	}
+}
+
+func TestGroupPolicyAllow(t *testing.T) {
+	tests := []struct {
+		desc   string
+		groups []string
+		op     string
+		path   string
+		want   bool
+	}{
+		{"group-based allow: netops may read a direct interface child", []string{"netops"}, "GET", "/configuration/interfaces/eth0", true},
+		{"glob-based deny of a subtree while allowing its parent: netops may not read a grandchild", []string{"netops"}, "GET", "/configuration/interfaces/eth0/subinterfaces", false},
+		{"admins may read deep into the subtree netops cannot", []string{"admins"}, "GET", "/configuration/interfaces/eth0/subinterfaces", true},
+		{"netops may not set", []string{"netops"}, "SET", "/configuration/interfaces/eth0", false},
+		{"unknown group is denied", []string{"mallory"}, "GET", "/configuration/interfaces/eth0", false},
+	}
+
+	policy := testGroupPolicy()
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			assert.Equal(t, tc.want, policy.Allow(tc.groups, tc.op, tc.path))
+		})
+	}
+}
+
+func TestNilGroupPolicyDeniesEverything(t *testing.T) {
+	var policy *GroupPolicy
+	assert.False(t, policy.Allow([]string{"netops"}, "GET", "/"))
+}
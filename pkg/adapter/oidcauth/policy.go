@@ -0,0 +1,97 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidcauth
+
+import "strings"
+
+// GroupRule grants any identity in Groups permission to perform any of Ops (gNMI RPC names, e.g.
+// "GET", "SET", "SUBSCRIBE") against any path matching any of Paths.
+type GroupRule struct {
+	Groups []string `yaml:"groups"`
+	Ops    []string `yaml:"ops"`
+	Paths  []string `yaml:"paths"`
+}
+
+// GroupPolicy binds identity groups (an OIDC token's "groups" claim) to the GroupRules they are
+// allowed. A request is denied unless some rule explicitly allows it; there is no default-allow.
+type GroupPolicy struct {
+	Rules []GroupRule
+}
+
+// Allow reports whether an identity in groups may perform op against path, per p's rules. A nil
+// GroupPolicy allows nothing.
+func (p *GroupPolicy) Allow(groups []string, op, path string) bool {
+	if p == nil {
+		return false
+	}
+	for _, rule := range p.Rules {
+		if !containsAny(rule.Groups, groups) || !contains(rule.Ops, op) {
+			continue
+		}
+		for _, glob := range rule.Paths {
+			if pathMatchesGlob(glob, path) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func contains(haystack []string, needle string) bool {
+	for _, s := range haystack {
+		if s == needle {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAny(haystack, needles []string) bool {
+	for _, n := range needles {
+		if contains(haystack, n) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathMatchesGlob reports whether the slash-separated path matches glob, where a "*" path segment
+// matches exactly one segment and a "**" segment matches zero or more segments. For example,
+// "/configuration/interfaces/**" matches "/configuration/interfaces" and any of its descendants, but
+// not "/configuration/system".
+func pathMatchesGlob(glob, path string) bool {
+	return matchSegments(splitPath(glob), splitPath(path))
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+func matchSegments(glob, path []string) bool {
+	if len(glob) == 0 {
+		return len(path) == 0
+	}
+	switch glob[0] {
+	case "**":
+		if matchSegments(glob[1:], path) {
+			return true
+		}
+		return len(path) > 0 && matchSegments(glob, path[1:])
+	case "*":
+		return len(path) > 0 && matchSegments(glob[1:], path[1:])
+	default:
+		return len(path) > 0 && glob[0] == path[0] && matchSegments(glob[1:], path[1:])
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidcauth
+
+import (
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pkg/errors"
+)
+
+// IssuerConfig describes one OIDC issuer an Authenticator will accept tokens from.
+type IssuerConfig struct {
+	// IssuerURL must match the token's "iss" claim exactly.
+	IssuerURL string `yaml:"issuer_url"`
+	// JWKSURL is fetched to obtain the issuer's signing keys.
+	JWKSURL string `yaml:"jwks_url"`
+	// ClientID, if set, must appear in the token's "azp" claim, or its "aud" claim when "azp" is
+	// absent, in addition to satisfying Audiences.
+	ClientID string `yaml:"client_id"`
+	// Audiences lists the values the token's "aud" claim must intersect with.
+	Audiences []string `yaml:"audiences"`
+}
+
+// verifier verifies RS256-signed JWTs issued by a single IssuerConfig.
+type verifier struct {
+	config IssuerConfig
+	keys   *keySet
+}
+
+func newVerifier(config IssuerConfig) *verifier {
+	return &verifier{config: config, keys: newKeySet(config.JWKSURL)}
+}
+
+// verify checks raw's signature, issuer, and audience against v's IssuerConfig, returning the
+// Identity its claims describe.
+func (v *verifier) verify(raw string) (*Identity, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, errors.Errorf("unsupported signing method %v", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		return v.keys.key(kid)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "token signature verification failed")
+	}
+
+	if iss, _ := claims["iss"].(string); iss != v.config.IssuerURL {
+		return nil, errors.Errorf("token issuer %q does not match configured issuer %q", iss, v.config.IssuerURL)
+	}
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return nil, errors.New("token is expired")
+	}
+	if err := v.verifyAudience(claims); err != nil {
+		return nil, err
+	}
+	if err := v.verifyClientID(claims); err != nil {
+		return nil, err
+	}
+
+	return &Identity{
+		Subject: subjectClaim(claims),
+		Groups:  groupsClaim(claims),
+		Claims:  claims,
+	}, nil
+}
+
+// verifyAudience reports whether claims carries an "aud" entry in v.config.Audiences. An empty
+// Audiences accepts any audience.
+func (v *verifier) verifyAudience(claims jwt.MapClaims) error {
+	if len(v.config.Audiences) == 0 {
+		return nil
+	}
+	for _, aud := range audienceClaim(claims) {
+		for _, want := range v.config.Audiences {
+			if aud == want {
+				return nil
+			}
+		}
+	}
+	return errors.Errorf("token audience %v does not intersect configured audiences %v", audienceClaim(claims), v.config.Audiences)
+}
+
+// verifyClientID reports whether claims identifies v.config.ClientID as an authorized party: the
+// token's "azp" claim when present, falling back to its "aud" claim otherwise. An empty ClientID
+// skips this check.
+func (v *verifier) verifyClientID(claims jwt.MapClaims) error {
+	if v.config.ClientID == "" {
+		return nil
+	}
+	if azp, ok := claims["azp"].(string); ok {
+		if azp != v.config.ClientID {
+			return errors.Errorf("token authorized party %q does not match configured client_id %q", azp, v.config.ClientID)
+		}
+		return nil
+	}
+	for _, aud := range audienceClaim(claims) {
+		if aud == v.config.ClientID {
+			return nil
+		}
+	}
+	return errors.Errorf("token has no azp claim and its audience %v does not include configured client_id %q", audienceClaim(claims), v.config.ClientID)
+}
+
+func subjectClaim(claims jwt.MapClaims) string {
+	sub, _ := claims["sub"].(string)
+	return sub
+}
+
+func audienceClaim(claims jwt.MapClaims) []string {
+	switch aud := claims["aud"].(type) {
+	case string:
+		return []string{aud}
+	case []interface{}:
+		out := make([]string, 0, len(aud))
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+// groupsClaim reads the token's "groups" claim, tolerating both a JSON array of strings and its
+// absence.
+func groupsClaim(claims jwt.MapClaims) []string {
+	raw, ok := claims["groups"].([]interface{})
+	if !ok {
+		return nil
+	}
+	groups := make([]string, 0, len(raw))
+	for _, g := range raw {
+		if s, ok := g.(string); ok {
+			groups = append(groups, s)
+		}
+	}
+	return groups
+}
@@ -0,0 +1,155 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package adapter implements a gnmi server that adapts to a netconf device.
+package adapter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/damianoneill/net/v2/netconf/ops"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+// ModelRegistry holds the YANG vendor packs (e.g. Junos, IOS-XR, OpenConfig) available to the
+// adapter, keyed by module name, and builds a Model from the subset a given device actually
+// advertises in its NETCONF <hello> capabilities exchange. This decouples the adapter from a single
+// vendor build: packs can be side-loaded via LoadDir, or registered directly by a Go plugin.
+type ModelRegistry struct {
+	mu      sync.RWMutex
+	entries map[string]*registryEntry
+}
+
+type registryEntry struct {
+	modelData *gnmi.ModelData
+	schema    *yang.Entry
+}
+
+// NewModelRegistry returns an empty registry.
+func NewModelRegistry() *ModelRegistry {
+	return &ModelRegistry{entries: make(map[string]*registryEntry)}
+}
+
+// Register adds a vendor pack's schema tree to the registry, keyed by module name.
+func (r *ModelRegistry) Register(name, org, version string, schemaTree *yang.Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[name] = &registryEntry{
+		modelData: &gnmi.ModelData{Name: name, Organization: org, Version: version},
+		schema:    schemaTree,
+	}
+}
+
+// LoadDir walks dir for *.yang files, parses each with goyang, and Registers the resulting schema
+// tree of every top-level module found.
+func (r *ModelRegistry) LoadDir(dir string) error {
+	ms := yang.NewModules()
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(path, ".yang") {
+			return err
+		}
+		return ms.Read(path)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read YANG modules from %s: %w", dir, err)
+	}
+	if errs := ms.Process(); len(errs) > 0 {
+		return fmt.Errorf("failed to process YANG modules in %s: %v", dir, errs)
+	}
+
+	for name, m := range ms.Modules {
+		r.Register(name, yangValueName(m.Organization), yangValueName(m.YangVersion), yang.ToEntry(m))
+	}
+	return nil
+}
+
+// yangValueName returns v's Name, or the empty string if v is nil. Organization and yang-version
+// are optional YANG module statements, so goyang leaves the corresponding *yang.Value unset when a
+// module doesn't declare them.
+func yangValueName(v *yang.Value) string {
+	if v == nil {
+		return ""
+	}
+	return v.Name
+}
+
+// SelectForDevice builds a Model from the intersection of the registry's modules and the module
+// names a device advertised in its NETCONF <hello> capabilities exchange.
+func (r *ModelRegistry) SelectForDevice(deviceCapabilities []string) (*Model, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var data []*gnmi.ModelData
+	root := &yang.Entry{Dir: map[string]*yang.Entry{}}
+
+	for _, capability := range deviceCapabilities {
+		entry, ok := r.entries[moduleNameFromCapability(capability)]
+		if !ok {
+			continue
+		}
+		data = append(data, entry.modelData)
+		for childName, child := range entry.schema.Dir {
+			root.Dir[childName] = child
+		}
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("no registered YANG module matched the device's advertised capabilities")
+	}
+	return NewModel(data, root), nil
+}
+
+// byName builds a Model from the single registered module name, for callers (e.g.
+// StaticTargetResolver) that bind a target to a named vendor pack directly rather than selecting one
+// by advertised NETCONF capabilities.
+func (r *ModelRegistry) byName(name string) (*Model, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entry, ok := r.entries[name]
+	if !ok {
+		return nil, false
+	}
+	return NewModel([]*gnmi.ModelData{entry.modelData}, entry.schema), true
+}
+
+// moduleNameFromCapability extracts the module name from a NETCONF capability URI of the form
+// "http://example.com/yang/module-name?module=name&revision=...", falling back to the raw
+// capability string for capabilities that are not parameterised that way (e.g. base capabilities).
+func moduleNameFromCapability(capability string) string {
+	const moduleParam = "module="
+	idx := strings.Index(capability, moduleParam)
+	if idx < 0 {
+		return capability
+	}
+	rest := capability[idx+len(moduleParam):]
+	if amp := strings.Index(rest, "&"); amp >= 0 {
+		rest = rest[:amp]
+	}
+	return rest
+}
+
+// NewAdapterFromRegistry selects the Model for a device from its advertised NETCONF capabilities
+// and returns an Adapter bound to it, mirroring NewAdapter.
+func NewAdapterFromRegistry(registry *ModelRegistry, deviceCapabilities []string, ncs ops.OpSession) (gnmi.GNMIServer, error) {
+	m, err := registry.SelectForDevice(deviceCapabilities)
+	if err != nil {
+		return nil, err
+	}
+	return NewAdapter(m, ncs)
+}
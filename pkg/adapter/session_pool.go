@@ -0,0 +1,159 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package adapter implements a gnmi server that adapts to a netconf device.
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/damianoneill/net/v2/netconf/ops"
+	log "k8s.io/klog"
+)
+
+// defaultMaxSessions bounds how many concurrent NETCONF sessions a sessionPool will dial for a
+// single target when no explicit limit is configured.
+const defaultMaxSessions = 4
+
+// SessionFactory dials a new NETCONF session to a target.
+type SessionFactory func(ctx context.Context) (ops.OpSession, error)
+
+// sessionPool maintains a small set of concurrently usable NETCONF sessions for a single target, so
+// that concurrent gNMI RPCs can be dispatched to the device in parallel, and transparently re-dials
+// with exponential backoff when a session's transport has failed.
+type sessionPool struct {
+	dial        SessionFactory
+	maxSessions int
+
+	mu    sync.Mutex
+	idle  []ops.OpSession
+	count int
+}
+
+// newSessionPool returns a pool that dials sessions via dial, up to maxSessions concurrently.
+func newSessionPool(dial SessionFactory, maxSessions int) *sessionPool {
+	if maxSessions <= 0 {
+		maxSessions = defaultMaxSessions
+	}
+	return &sessionPool{dial: dial, maxSessions: maxSessions}
+}
+
+// Get acquires a session from the pool, dialing a new one (subject to maxSessions) if none are
+// idle, blocking on the dial's exponential backoff until ctx is done.
+func (p *sessionPool) Get(ctx context.Context) (ops.OpSession, error) {
+	p.mu.Lock()
+	if n := len(p.idle); n > 0 {
+		s := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.mu.Unlock()
+		return s, nil
+	}
+	if p.count >= p.maxSessions {
+		p.mu.Unlock()
+		return nil, fmt.Errorf("session pool exhausted (max %d sessions in use)", p.maxSessions)
+	}
+	p.count++
+	p.mu.Unlock()
+
+	s, err := p.dialWithBackoff(ctx)
+	if err != nil {
+		p.mu.Lock()
+		p.count--
+		p.mu.Unlock()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Put returns a healthy session to the pool for reuse.
+func (p *sessionPool) Put(s ops.OpSession) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.idle = append(p.idle, s)
+}
+
+// Discard retires a session whose transport has failed, dropping it from the pool and redialing a
+// replacement in the background so capacity recovers without blocking the caller that detected the
+// failure.
+func (p *sessionPool) Discard(s ops.OpSession) {
+	if closer, ok := s.(interface{ Close() error }); ok {
+		_ = closer.Close()
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		replacement, err := p.dialWithBackoff(ctx)
+		if err != nil {
+			log.Errorf("failed to reconnect a NETCONF session: %v", err)
+			p.mu.Lock()
+			p.count--
+			p.mu.Unlock()
+			return
+		}
+		p.Put(replacement)
+	}()
+}
+
+// dialWithBackoff retries dial with exponential backoff (capped at maxDialBackoff) until it
+// succeeds or ctx is done.
+func (p *sessionPool) dialWithBackoff(ctx context.Context) (ops.OpSession, error) {
+	const (
+		initialBackoff = 500 * time.Millisecond
+		maxDialBackoff = 30 * time.Second
+	)
+	backoff := initialBackoff
+	for {
+		s, err := p.dial(ctx)
+		if err == nil {
+			return s, nil
+		}
+		log.Warningf("NETCONF dial failed, retrying in %s: %v", backoff, err)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxDialBackoff {
+			backoff = maxDialBackoff
+		}
+	}
+}
+
+// Healthy reports whether the pool can currently serve a session, i.e. it has an idle session or
+// spare capacity to dial one.
+func (p *sessionPool) Healthy() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.idle) > 0 || p.count < p.maxSessions
+}
+
+// Close closes every currently idle session. Sessions that are out on loan (acquired via Get but not
+// yet Put/Discard) are left to their caller; the pool no longer accepts Get calls from the owner that
+// closed it.
+func (p *sessionPool) Close() {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+
+	for _, s := range idle {
+		if closer, ok := s.(interface{ Close() error }); ok {
+			_ = closer.Close()
+		}
+	}
+}
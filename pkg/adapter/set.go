@@ -22,28 +22,226 @@ import (
 	"encoding/xml"
 	"fmt"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/openconfig/goyang/pkg/yang"
 
 	"github.com/damianoneill/net/v2/netconf/ops"
+	"github.com/onosproject/gnmi-netconf-adapter/pkg/adapter/audit"
+	"github.com/onosproject/gnmi-netconf-adapter/pkg/adapter/telemetry"
 	"github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/openconfig/gnmi/value"
+	"go.opentelemetry.io/otel/api/trace"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	log "k8s.io/klog"
 )
 
-// Set implements the Set RPC in gNMI spec.
+// defaultConfirmedCommitTimeout bounds how long a candidate commit made with :confirmed-commit
+// survives before the device automatically rolls it back if the follow-up persisting commit is
+// never received, e.g. because the adapter process died mid-transaction.
+const defaultConfirmedCommitTimeout = 30 * time.Second
+
+// candidateCapability and confirmedCommitCapability are substrings of the NETCONF capability URNs
+// (urn:ietf:params:netconf:capability:candidate:1.0 and
+// urn:ietf:params:netconf:capability:confirmed-commit:1.1) that a device advertises in its NETCONF
+// hello when it supports, respectively, the candidate configuration datastore and confirmed commit.
+const (
+	candidateCapability       = "urn:ietf:params:netconf:capability:candidate"
+	confirmedCommitCapability = "urn:ietf:params:netconf:capability:confirmed-commit"
+)
+
+// supportsCandidateTransaction reports whether ncs's advertised NETCONF capabilities include both
+// :candidate and :confirmed-commit.
+func supportsCandidateTransaction(ncs ops.OpSession) bool {
+	var hasCandidate, hasConfirmedCommit bool
+	for _, c := range ncs.ServerCapabilities() {
+		if strings.Contains(c, candidateCapability) {
+			hasCandidate = true
+		}
+		if strings.Contains(c, confirmedCommitCapability) {
+			hasConfirmedCommit = true
+		}
+	}
+	return hasCandidate && hasConfirmedCommit
+}
+
+// SetConfirmedCommitTimeout overrides the timeout passed to the candidate transaction's confirmed
+// commit by Set; a non-positive value restores defaultConfirmedCommitTimeout.
+func (a *Adapter) SetConfirmedCommitTimeout(d time.Duration) {
+	a.confirmedCommitTimeout = d
+}
+
+func (a *Adapter) confirmedCommitTimeoutOrDefault() time.Duration {
+	if a.confirmedCommitTimeout <= 0 {
+		return defaultConfirmedCommitTimeout
+	}
+	return a.confirmedCommitTimeout
+}
+
+// Set implements the Set RPC in gNMI spec. When the target device advertises :candidate and
+// :confirmed-commit, the request's edits are applied as a single all-or-nothing transaction against
+// the candidate datastore (see setCandidateTransaction); otherwise each edit is applied directly
+// against the running datastore as its own netconf edit-config (see setPerEdit), which does not
+// roll back earlier edits in the same request if a later one fails.
 func (a *Adapter) Set(ctx context.Context, req *gnmi.SetRequest) (*gnmi.SetResponse, error) {
 
+	ctx, span := telemetry.Tracer().Start(ctx, "adapter.Set")
+	defer span.End()
+
 	prefix := req.GetPrefix()
+	target := prefix.GetTarget()
+
+	m, ncs, err := a.resolveTarget(ctx, target)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "no NETCONF session available: %v", err)
+	}
+
+	if supportsCandidateTransaction(ncs) {
+		resp, err := a.setCandidateTransaction(ctx, m, ncs, prefix, req)
+		a.releaseTarget(target, m, ncs, err != nil)
+		return resp, err
+	}
+
+	a.releaseTarget(target, m, ncs, false)
+	return a.setPerEdit(ctx, prefix, req)
+}
+
+// setCandidateTransaction applies every edit in req against the candidate datastore under a single
+// lock, and persists them with a confirmed commit only once they all succeed and candidate validates
+// cleanly; any failure at any stage discards the candidate's changes and unlocks it, leaving running
+// config untouched.
+// Reference: https://github.com/openconfig/reference/blob/master/rpc/gnmi/gnmi-specification.md#34-modifying-state
+func (a *Adapter) setCandidateTransaction(ctx context.Context, m *Model, ncs ops.OpSession, prefix *gnmi.Path, req *gnmi.SetRequest) (*gnmi.SetResponse, error) {
+
+	ctx, span := telemetry.Tracer().Start(ctx, "adapter.setCandidateTransaction", trace.WithAttributes(telemetry.KeyDeviceTarget.String(prefix.GetTarget())))
+	defer span.End()
+
+	if err := ncs.Lock(ops.CandidateCfg); err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to lock candidate datastore: %v", err)
+	}
+
+	results, err := a.applyEditsToCandidate(ctx, m, ncs, prefix, req)
+	if err != nil {
+		return nil, a.rollbackCandidate(ncs, err)
+	}
+
+	if err := validateCandidate(ncs); err != nil {
+		return nil, a.rollbackCandidate(ncs, status.Errorf(codes.FailedPrecondition, "candidate validation failed: %v", err))
+	}
+
+	if err := confirmedCommit(ncs, a.confirmedCommitTimeoutOrDefault()); err != nil {
+		return nil, a.rollbackCandidate(ncs, status.Errorf(codes.Unknown, "confirmed commit failed: %v", err))
+	}
+
+	if err := persistCommit(ncs); err != nil {
+		return nil, a.rollbackCandidate(ncs, status.Errorf(codes.Unknown, "commit to persist confirmed changes failed: %v", err))
+	}
+
+	if err := ncs.Unlock(ops.CandidateCfg); err != nil {
+		log.Warningf("failed to unlock candidate datastore after a successful commit: %v", err)
+	}
+
+	return &gnmi.SetResponse{Prefix: prefix, Response: results}, nil
+}
+
+// validateReq is a <validate> NETCONF RPC request (RFC 6241 section 8.6), used to check that the
+// candidate datastore is syntactically and semantically valid before it is committed.
+type validateReq struct {
+	XMLName xml.Name        `xml:"validate"`
+	Source  *ops.ConfigType `xml:"source"`
+}
+
+// commitReq is a <commit> NETCONF RPC request (RFC 6241 section 8.3.4.1). When Confirmed is set,
+// the device must receive a second, unconfirmed commit within ConfirmTimeout seconds or it
+// automatically reverts to the configuration that was running before the confirmed commit.
+type commitReq struct {
+	XMLName        xml.Name  `xml:"commit"`
+	Confirmed      *struct{} `xml:"confirmed,omitempty"`
+	ConfirmTimeout int       `xml:"confirm-timeout,omitempty"`
+}
+
+// validateCandidate issues a <validate> request against ncs's candidate datastore, ahead of
+// committing it.
+func validateCandidate(ncs ops.OpSession) error {
+	_, err := ncs.Execute(&validateReq{Source: &ops.ConfigType{Type: "<" + ops.CandidateCfg + "/>"}})
+	return err
+}
+
+// confirmedCommit issues a confirmed <commit> request for ncs's candidate datastore with the given
+// confirm-timeout; the commit is automatically rolled back by the device unless persistCommit
+// follows within timeout.
+func confirmedCommit(ncs ops.OpSession, timeout time.Duration) error {
+	_, err := ncs.Execute(&commitReq{Confirmed: &struct{}{}, ConfirmTimeout: int(timeout.Seconds())})
+	return err
+}
+
+// persistCommit issues the unconfirmed <commit> request that makes a preceding confirmedCommit
+// permanent.
+func persistCommit(ncs ops.OpSession) error {
+	_, err := ncs.Execute(&commitReq{})
+	return err
+}
+
+// applyEditsToCandidate issues every delete/replace/update in req against the candidate datastore,
+// in the gNMI-spec-mandated delete, replace, update order, stopping at the first failure.
+func (a *Adapter) applyEditsToCandidate(ctx context.Context, m *Model, ncs ops.OpSession, prefix *gnmi.Path, req *gnmi.SetRequest) ([]*gnmi.UpdateResult, error) {
+	target := prefix.GetTarget()
 	var results []*gnmi.UpdateResult
 
-	// Execute operations in order.
-	// Reference: https://github.com/openconfig/reference/blob/master/rpc/gnmi/gnmi-specification.md#34-modifying-state
+	for _, path := range req.GetDelete() {
+		res, err := a.applyEdit(ctx, m, ncs, ops.CandidateCfg, target, gnmi.UpdateResult_DELETE, prefix, path, nil)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, res)
+	}
+	for _, upd := range req.GetReplace() {
+		res, err := a.applyEdit(ctx, m, ncs, ops.CandidateCfg, target, gnmi.UpdateResult_REPLACE, prefix, upd.GetPath(), upd.GetVal())
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, res)
+	}
+	for _, upd := range req.GetUpdate() {
+		res, err := a.applyEdit(ctx, m, ncs, ops.CandidateCfg, target, gnmi.UpdateResult_UPDATE, prefix, upd.GetPath(), upd.GetVal())
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// rollbackCandidate discards the candidate datastore's uncommitted changes and unlocks it after
+// cause has aborted a transaction, returning a status error that carries cause's code and message
+// plus the rollback's own outcome.
+func (a *Adapter) rollbackCandidate(ncs ops.OpSession, cause error) error {
+	discardErr := ncs.Discard()
+	unlockErr := ncs.Unlock(ops.CandidateCfg)
+
+	st, _ := status.FromError(cause)
+	switch {
+	case discardErr != nil:
+		return status.Errorf(st.Code(), "%s (rollback failed: discard-changes: %v)", st.Message(), discardErr)
+	case unlockErr != nil:
+		return status.Errorf(st.Code(), "%s (rolled back via discard-changes, but unlock failed: %v)", st.Message(), unlockErr)
+	default:
+		return status.Errorf(st.Code(), "%s (rolled back via discard-changes)", st.Message())
+	}
+}
+
+// setPerEdit is the fallback Set behavior for devices that lack :candidate: it applies each edit
+// directly against the running datastore as its own netconf edit-config, in the gNMI-spec-mandated
+// delete, replace, update order. A failure partway through leaves earlier edits in the same request
+// applied, since running config has no equivalent of a candidate rollback.
+func (a *Adapter) setPerEdit(ctx context.Context, prefix *gnmi.Path, req *gnmi.SetRequest) (*gnmi.SetResponse, error) {
+	var results []*gnmi.UpdateResult
 
 	// Execute Deletes
 	for _, path := range req.GetDelete() {
-		res, grpcStatusError := a.executeOperation(gnmi.UpdateResult_DELETE, prefix, path, nil)
+		res, grpcStatusError := a.executeOperation(ctx, gnmi.UpdateResult_DELETE, prefix, path, nil)
 		if grpcStatusError != nil {
 			return nil, grpcStatusError
 		}
@@ -52,7 +250,7 @@ func (a *Adapter) Set(ctx context.Context, req *gnmi.SetRequest) (*gnmi.SetRespo
 
 	// Execute Replaces
 	for _, upd := range req.GetReplace() {
-		res, grpcStatusError := a.executeOperation(gnmi.UpdateResult_REPLACE, prefix, upd.GetPath(), upd.GetVal())
+		res, grpcStatusError := a.executeOperation(ctx, gnmi.UpdateResult_REPLACE, prefix, upd.GetPath(), upd.GetVal())
 		if grpcStatusError != nil {
 			return nil, grpcStatusError
 		}
@@ -61,7 +259,7 @@ func (a *Adapter) Set(ctx context.Context, req *gnmi.SetRequest) (*gnmi.SetRespo
 
 	// Execute Updates
 	for _, upd := range req.GetUpdate() {
-		res, grpcStatusError := a.executeOperation(gnmi.UpdateResult_UPDATE, prefix, upd.GetPath(), upd.GetVal())
+		res, grpcStatusError := a.executeOperation(ctx, gnmi.UpdateResult_UPDATE, prefix, upd.GetPath(), upd.GetVal())
 		if grpcStatusError != nil {
 			return nil, grpcStatusError
 		}
@@ -74,17 +272,69 @@ func (a *Adapter) Set(ctx context.Context, req *gnmi.SetRequest) (*gnmi.SetRespo
 	}, nil
 }
 
-// executeOperation executes a gNMI Set operation mapping it to a netconf edit-config operation.
-func (a *Adapter) executeOperation(op gnmi.UpdateResult_Operation, prefix, path *gnmi.Path, val *gnmi.TypedValue) (*gnmi.UpdateResult, error) {
+// executeOperation executes a gNMI Set operation mapping it to a netconf edit-config operation
+// against the running datastore.
+func (a *Adapter) executeOperation(ctx context.Context, op gnmi.UpdateResult_Operation, prefix, path *gnmi.Path, val *gnmi.TypedValue) (*gnmi.UpdateResult, error) {
 
-	request, err := a.gnmiToNetconfOperation(op, prefix, path, val)
+	target := targetFor(prefix, path)
+	m, ncs, err := a.resolveTarget(ctx, target)
 	if err != nil {
-		return nil, err
+		return nil, status.Errorf(codes.Unavailable, "no NETCONF session available: %v", err)
+	}
+
+	res, err := a.applyEdit(ctx, m, ncs, ops.RunningCfg, target, op, prefix, path, val)
+	a.releaseTarget(target, m, ncs, err != nil)
+	return res, err
+}
+
+// datastoreLabel names datastore for telemetry attributes.
+func datastoreLabel(datastore string) string {
+	if datastore == ops.CandidateCfg {
+		return "candidate"
 	}
+	return "running"
+}
+
+// applyEdit maps a single gNMI Set operation to a netconf edit-config request and issues it against
+// ncs and datastore, recording a child span, edit-config latency/failure telemetry, and an audit
+// event for it.
+func (a *Adapter) applyEdit(ctx context.Context, m *Model, ncs ops.OpSession, datastore string, target string, op gnmi.UpdateResult_Operation, prefix, path *gnmi.Path, val *gnmi.TypedValue) (*gnmi.UpdateResult, error) {
 
-	err = a.ncs.EditConfigCfg(ops.RunningCfg, request)
+	fullPath := path
+	if prefix != nil {
+		fullPath = gnmiFullPath(prefix, path)
+	}
+	operation := mapOperation(op)
+
+	ctx, span := telemetry.Tracer().Start(ctx, "adapter.applyEdit", trace.WithAttributes(
+		telemetry.KeyNetconfDatastore.String(datastoreLabel(datastore)),
+		telemetry.KeyNetconfOperation.String(operation),
+		telemetry.KeyGNMIPath.String(fullPath.String()),
+		telemetry.KeyDeviceTarget.String(target),
+	))
+	defer span.End()
+
+	editStart := time.Now()
+	var filter string
+	var editErr error
+	defer func() { a.emitSetEvent(ctx, fullPath, filter, editStart, editErr) }()
+
+	request, err := a.gnmiToNetconfOperation(m, op, prefix, path, val)
 	if err != nil {
-		return nil, status.Errorf(codes.Unknown, "edit failed %s", err)
+		editErr = err
+		return nil, err
+	}
+	if s, ok := request.(string); ok {
+		filter = s
+	}
+
+	start := time.Now()
+	editErr = ncs.EditConfigCfg(datastore, request)
+	telemetry.RecordEditConfigLatency(ctx, datastoreLabel(datastore), operation, time.Since(start))
+	if editErr != nil {
+		telemetry.RecordOperationFailure(ctx, operation)
+		editErr = status.Errorf(codes.Unknown, "edit failed %s", editErr)
+		return nil, editErr
 	}
 
 	return &gnmi.UpdateResult{
@@ -93,15 +343,31 @@ func (a *Adapter) executeOperation(op gnmi.UpdateResult_Operation, prefix, path
 	}, nil
 }
 
-// gnmiToNetconfOperation maps a gNMI set operation to a netconfig edit-config operation.
-func (a *Adapter) gnmiToNetconfOperation(op gnmi.UpdateResult_Operation, prefix, path *gnmi.Path, inval *gnmi.TypedValue) (interface{}, error) {
+// emitSetEvent publishes an audit.SetEvent for a single NETCONF edit issued on behalf of a gNMI Set
+// RPC, if the Adapter was configured with WithAuditEmitter.
+func (a *Adapter) emitSetEvent(ctx context.Context, path *gnmi.Path, filter string, start time.Time, err error) {
+	if a.audit == nil {
+		return
+	}
+	a.audit.EmitSetEvent(ctx, audit.SetEvent{
+		Identity:      audit.IdentityFromContext(ctx),
+		Path:          path.String(),
+		NetconfFilter: filter,
+		Code:          status.Code(err).String(),
+		Duration:      time.Since(start),
+	})
+}
+
+// gnmiToNetconfOperation maps a gNMI set operation to a netconfig edit-config operation, using m to
+// resolve the schema entry for path.
+func (a *Adapter) gnmiToNetconfOperation(m *Model, op gnmi.UpdateResult_Operation, prefix, path *gnmi.Path, inval *gnmi.TypedValue) (interface{}, error) {
 
 	fullPath := path
 	if prefix != nil {
 		fullPath = gnmiFullPath(prefix, path)
 	}
 
-	entry := a.getSchemaEntryForPath(fullPath)
+	entry := getSchemaEntryForPath(m, fullPath)
 	if entry == nil {
 		return nil, status.Errorf(codes.NotFound, "path %v not found (Test)", fullPath)
 	}
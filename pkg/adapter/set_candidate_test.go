@@ -0,0 +1,151 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package adapter
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/damianoneill/net/v2/netconf/common"
+	"github.com/damianoneill/net/v2/netconf/ops"
+	"github.com/damianoneill/net/v2/netconf/ops/mocks"
+	"github.com/golang/protobuf/proto"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/stretchr/testify/mock"
+	assert "github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var candidateCapabilities = []string{
+	"urn:ietf:params:netconf:capability:candidate:1.0",
+	"urn:ietf:params:netconf:capability:confirmed-commit:1.1",
+}
+
+func newReplaceRequest(t *testing.T) *gnmi.SetRequest {
+	var path gnmi.Path
+	assert.NoError(t, proto.UnmarshalText(`elem: <name: "version" >`, &path))
+	return &gnmi.SetRequest{
+		Prefix: &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "configuration"}}},
+		Replace: []*gnmi.Update{{
+			Path: &path,
+			Val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: "ABC"}},
+		}},
+	}
+}
+
+func TestSetCandidateTransactionCommits(t *testing.T) {
+	mockNc := &mocks.OpSession{}
+	mockNc.On("ServerCapabilities").Return(candidateCapabilities)
+	mockNc.On("Lock", ops.CandidateCfg).Return(nil)
+	mockNc.On("EditConfigCfg", ops.CandidateCfg, `<configuration><version operation="replace">ABC</version></configuration>`).Return(nil)
+	mockNc.On("Execute", mock.AnythingOfType("*adapter.validateReq")).Return(&common.RPCReply{}, nil)
+	mockNc.On("Execute", mock.AnythingOfType("*adapter.commitReq")).Return(&common.RPCReply{}, nil)
+	mockNc.On("Unlock", ops.CandidateCfg).Return(nil)
+
+	s, err := NewAdapter(model, mockNc)
+	assert.NoError(t, err)
+
+	_, err = s.Set(context.TODO(), newReplaceRequest(t))
+	assert.NoError(t, err)
+	mockNc.AssertExpectations(t)
+}
+
+func TestSetCandidateTransactionRollsBackOnEditFailure(t *testing.T) {
+	mockNc := &mocks.OpSession{}
+	mockNc.On("ServerCapabilities").Return(candidateCapabilities)
+	mockNc.On("Lock", ops.CandidateCfg).Return(nil)
+	mockNc.On("EditConfigCfg", ops.CandidateCfg, `<configuration><version operation="replace">ABC</version></configuration>`).Return(errors.New("netconf failure"))
+	mockNc.On("Discard").Return(nil)
+	mockNc.On("Unlock", ops.CandidateCfg).Return(nil)
+
+	s, err := NewAdapter(model, mockNc)
+	assert.NoError(t, err)
+
+	_, err = s.Set(context.TODO(), newReplaceRequest(t))
+	assert.Error(t, err)
+
+	st, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.Unknown, st.Code())
+	assert.Contains(t, st.Message(), "rolled back via discard-changes")
+}
+
+func TestSetCandidateTransactionRollsBackOnValidateFailure(t *testing.T) {
+	mockNc := &mocks.OpSession{}
+	mockNc.On("ServerCapabilities").Return(candidateCapabilities)
+	mockNc.On("Lock", ops.CandidateCfg).Return(nil)
+	mockNc.On("EditConfigCfg", ops.CandidateCfg, `<configuration><version operation="replace">ABC</version></configuration>`).Return(nil)
+	mockNc.On("Execute", mock.AnythingOfType("*adapter.validateReq")).Return(nil, errors.New("inconsistent candidate"))
+	mockNc.On("Discard").Return(nil)
+	mockNc.On("Unlock", ops.CandidateCfg).Return(nil)
+
+	s, err := NewAdapter(model, mockNc)
+	assert.NoError(t, err)
+
+	_, err = s.Set(context.TODO(), newReplaceRequest(t))
+	assert.Error(t, err)
+
+	st, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.FailedPrecondition, st.Code())
+}
+
+func TestSetCandidateTransactionRollsBackOnConfirmedCommitFailure(t *testing.T) {
+	mockNc := &mocks.OpSession{}
+	mockNc.On("ServerCapabilities").Return(candidateCapabilities)
+	mockNc.On("Lock", ops.CandidateCfg).Return(nil)
+	mockNc.On("EditConfigCfg", ops.CandidateCfg, `<configuration><version operation="replace">ABC</version></configuration>`).Return(nil)
+	mockNc.On("Execute", mock.AnythingOfType("*adapter.validateReq")).Return(&common.RPCReply{}, nil)
+	mockNc.On("Execute", mock.AnythingOfType("*adapter.commitReq")).Return(nil, errors.New("lock held by another session"))
+	mockNc.On("Discard").Return(nil)
+	mockNc.On("Unlock", ops.CandidateCfg).Return(nil)
+
+	s, err := NewAdapter(model, mockNc)
+	assert.NoError(t, err)
+
+	_, err = s.Set(context.TODO(), newReplaceRequest(t))
+	assert.Error(t, err)
+
+	st, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.Unknown, st.Code())
+}
+
+func TestSetFallsBackToPerEditWithoutCandidateCapability(t *testing.T) {
+	mockNc := &mocks.OpSession{}
+	mockNc.On("ServerCapabilities").Return([]string{}) // no capabilities advertised
+	mockNc.On("EditConfigCfg", ops.RunningCfg, `<configuration><version operation="replace">ABC</version></configuration>`).Return(nil)
+
+	s, err := NewAdapter(model, mockNc)
+	assert.NoError(t, err)
+
+	_, err = s.Set(context.TODO(), newReplaceRequest(t))
+	assert.NoError(t, err)
+	mockNc.AssertExpectations(t)
+}
+
+func TestSetFallsBackToPerEditWithoutConfirmedCommitCapability(t *testing.T) {
+	mockNc := &mocks.OpSession{}
+	mockNc.On("ServerCapabilities").Return([]string{"urn:ietf:params:netconf:capability:candidate:1.0"})
+	mockNc.On("EditConfigCfg", ops.RunningCfg, `<configuration><version operation="replace">ABC</version></configuration>`).Return(nil)
+
+	s, err := NewAdapter(model, mockNc)
+	assert.NoError(t, err)
+
+	_, err = s.Set(context.TODO(), newReplaceRequest(t))
+	assert.NoError(t, err)
+	mockNc.AssertExpectations(t)
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/damianoneill/net/v2/netconf/ops/mocks"
 
 	"github.com/golang/protobuf/proto"
+	export "go.opentelemetry.io/otel/sdk/export/trace"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
@@ -31,12 +32,14 @@ import (
 
 	"github.com/onosproject/gnmi-netconf-adapter/pkg/adapter/modeldata"
 	"github.com/onosproject/gnmi-netconf-adapter/pkg/adapter/modeldata/gostruct"
+	"github.com/onosproject/gnmi-netconf-adapter/pkg/adapter/telemetry"
 )
 
-var (
-	// model is the model for test config server.
+// init populates the package-level model fixture declared in capabilities.go, for tests in this
+// package that use it directly instead of calling NewAdapter (e.g. TestSet, TestDelete, TestReplace).
+func init() {
 	model = NewModel(modeldata.ModelData, gostruct.SchemaTree["Device"])
-)
+}
 
 type gnmiSetTestCase struct {
 	desc        string                      // description of test case.
@@ -333,6 +336,9 @@ func TestReplace(t *testing.T) {
 
 func runTestSet(t *testing.T, m *Model, tc gnmiSetTestCase) {
 
+	recorder, restore := telemetry.InstallTestRecorder()
+	defer restore()
+
 	mockNc := &mocks.OpSession{}
 	mockNc.On("EditConfigCfg", ops.RunningCfg, tc.ncFilter).Return(tc.ncResponse)
 
@@ -366,6 +372,37 @@ func runTestSet(t *testing.T, m *Model, tc gnmiSetTestCase) {
 	if gotRetStatus.Code() != tc.wantRetCode {
 		t.Fatalf("got return code %v, want %v\nerror message: %v", gotRetStatus.Code(), tc.wantRetCode, err)
 	}
+
+	assertSetSpanParentage(t, recorder)
+}
+
+// assertSetSpanParentage checks that Set opened a root span and that the edit it issued was recorded
+// as a child of it, so the NETCONF edit-config call is visible as part of the same trace as the gNMI
+// RPC that triggered it.
+func assertSetSpanParentage(t *testing.T, recorder *telemetry.SpanRecorder) {
+
+	spans := recorder.Spans()
+
+	var setSpan, editSpan *export.SpanData
+	for _, span := range spans {
+		switch span.Name {
+		case "adapter.Set":
+			setSpan = span
+		case "adapter.applyEdit":
+			editSpan = span
+		}
+	}
+
+	if setSpan == nil {
+		t.Fatal("expected a recorded adapter.Set span")
+	}
+	if editSpan == nil {
+		// Failed edits (e.g. schema errors) never reach applyEdit; nothing further to assert.
+		return
+	}
+	if editSpan.ParentSpanID != setSpan.SpanContext.SpanID {
+		t.Fatalf("adapter.applyEdit span parent %v, want adapter.Set span %v", editSpan.ParentSpanID, setSpan.SpanContext.SpanID)
+	}
 }
 
 func getPathPrefix(prefix string) *gnmi.Path {
@@ -0,0 +1,153 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package adapter implements a gnmi server that adapts to a netconf device.
+package adapter
+
+import (
+	"context"
+	"time"
+
+	"github.com/onosproject/gnmi-netconf-adapter/pkg/adapter/audit"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Subscribe implements the Subscribe RPC in gNMI spec. It supports the ONCE, POLL, and STREAM
+// (SAMPLE and ON_CHANGE) subscription modes.
+func (a *Adapter) Subscribe(stream gnmi.GNMI_SubscribeServer) (err error) {
+	start := time.Now()
+	var sub *gnmi.SubscriptionList
+	defer func() { a.emitSubscribeEvent(stream.Context(), sub, start, err) }()
+
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+
+	sub = req.GetSubscribe()
+	if sub == nil {
+		return status.Error(codes.InvalidArgument, "first message on a Subscribe stream must carry a SubscriptionList")
+	}
+
+	switch sub.GetMode() {
+	case gnmi.SubscriptionList_ONCE:
+		if err := a.publishSubscriptionSet(stream, sub); err != nil {
+			return err
+		}
+		return stream.Send(&gnmi.SubscribeResponse{Response: &gnmi.SubscribeResponse_SyncResponse{SyncResponse: true}})
+	case gnmi.SubscriptionList_POLL:
+		return a.subscribePoll(stream, sub)
+	case gnmi.SubscriptionList_STREAM:
+		return a.subscribeStream(stream, sub)
+	default:
+		return status.Errorf(codes.InvalidArgument, "unsupported subscription mode %v", sub.GetMode())
+	}
+}
+
+// emitSubscribeEvent publishes an audit.SubscribeEvent for a completed Subscribe RPC, if the
+// Adapter was configured with WithAuditEmitter. sub is nil when the stream closed before a
+// SubscriptionList was received.
+func (a *Adapter) emitSubscribeEvent(ctx context.Context, sub *gnmi.SubscriptionList, start time.Time, err error) {
+	if a.audit == nil {
+		return
+	}
+	paths := make([]string, len(sub.GetSubscription()))
+	for i, s := range sub.GetSubscription() {
+		paths[i] = s.GetPath().String()
+	}
+	a.audit.EmitSubscribeEvent(ctx, audit.SubscribeEvent{
+		Identity: audit.IdentityFromContext(ctx),
+		Paths:    paths,
+		Code:     status.Code(err).String(),
+		Duration: time.Since(start),
+	})
+}
+
+// subscribePoll delivers a full walk of the requested paths every time the client sends a poll
+// trigger, until the client closes the stream.
+func (a *Adapter) subscribePoll(stream gnmi.GNMI_SubscribeServer, sub *gnmi.SubscriptionList) error {
+	if err := a.publishSubscriptionSet(stream, sub); err != nil {
+		return err
+	}
+	if err := stream.Send(&gnmi.SubscribeResponse{Response: &gnmi.SubscribeResponse_SyncResponse{SyncResponse: true}}); err != nil {
+		return err
+	}
+
+	for {
+		req, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		if req.GetPoll() == nil {
+			return status.Error(codes.InvalidArgument, "expected a poll trigger on a POLL subscription")
+		}
+		if err := a.publishSubscriptionSet(stream, sub); err != nil {
+			return err
+		}
+		if err := stream.Send(&gnmi.SubscribeResponse{Response: &gnmi.SubscribeResponse_SyncResponse{SyncResponse: true}}); err != nil {
+			return err
+		}
+	}
+}
+
+// subscribeStream runs a long-lived subscription, dispatching each requested path to the
+// subscriptionManager as either a SAMPLE (ticker-driven) or ON_CHANGE (NETCONF notification bridged)
+// subscription, and forwarding the resulting updates to the client until it disconnects.
+func (a *Adapter) subscribeStream(stream gnmi.GNMI_SubscribeServer, sub *gnmi.SubscriptionList) error {
+	mgr := a.subscriptionManager()
+	sess := mgr.start(stream.Context(), sub)
+	defer mgr.stop(sess)
+
+	if !sub.GetUpdatesOnly() {
+		if err := a.publishSubscriptionSet(stream, sub); err != nil {
+			return err
+		}
+	}
+	if err := stream.Send(&gnmi.SubscribeResponse{Response: &gnmi.SubscribeResponse_SyncResponse{SyncResponse: true}}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case resp, ok := <-sess.updates:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// publishSubscriptionSet performs a Get-style walk of every path in the subscription list and
+// streams the resulting notifications to the client. It is used directly by ONCE/POLL, and as the
+// initial snapshot for STREAM subscriptions that do not request updates_only.
+func (a *Adapter) publishSubscriptionSet(stream gnmi.GNMI_SubscribeServer, sub *gnmi.SubscriptionList) error {
+	getReq := &gnmi.GetRequest{Prefix: sub.GetPrefix(), UseModels: sub.GetUseModels(), Encoding: gnmi.Encoding_JSON}
+	for _, s := range sub.GetSubscription() {
+		n, err := a.processPath(stream.Context(), getReq, s.GetPath())
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(&gnmi.SubscribeResponse{Response: &gnmi.SubscribeResponse_Update{Update: n}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/damianoneill/net/v2/netconf/ops"
+	"github.com/damianoneill/net/v2/netconf/ops/mocks"
+	"github.com/golang/protobuf/proto"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/stretchr/testify/mock"
+	assert "github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/onosproject/gnmi-netconf-adapter/pkg/adapter/modeldata"
+	"github.com/onosproject/gnmi-netconf-adapter/pkg/adapter/modeldata/gostruct"
+)
+
+// fakeSubscribeStream is a minimal gnmi.GNMI_SubscribeServer usable from tests: requests queued on
+// recvCh are handed back by Recv (closing it makes Recv return io.EOF, as a real client disconnect
+// would), and every Send is appended to sent for later inspection.
+type fakeSubscribeStream struct {
+	ctx    context.Context
+	recvCh chan *gnmi.SubscribeRequest
+
+	mu   sync.Mutex
+	sent []*gnmi.SubscribeResponse
+}
+
+func newFakeSubscribeStream(ctx context.Context) *fakeSubscribeStream {
+	return &fakeSubscribeStream{ctx: ctx, recvCh: make(chan *gnmi.SubscribeRequest, 2)}
+}
+
+func (f *fakeSubscribeStream) Send(r *gnmi.SubscribeResponse) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, r)
+	return nil
+}
+
+func (f *fakeSubscribeStream) Recv() (*gnmi.SubscribeRequest, error) {
+	req, ok := <-f.recvCh
+	if !ok {
+		return nil, io.EOF
+	}
+	return req, nil
+}
+
+func (f *fakeSubscribeStream) responses() []*gnmi.SubscribeResponse {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]*gnmi.SubscribeResponse, len(f.sent))
+	copy(out, f.sent)
+	return out
+}
+
+func (f *fakeSubscribeStream) Context() context.Context    { return f.ctx }
+func (f *fakeSubscribeStream) SetHeader(metadata.MD) error  { return nil }
+func (f *fakeSubscribeStream) SendHeader(metadata.MD) error { return nil }
+func (f *fakeSubscribeStream) SetTrailer(metadata.MD)       {}
+func (f *fakeSubscribeStream) SendMsg(interface{}) error    { return nil }
+func (f *fakeSubscribeStream) RecvMsg(interface{}) error    { return nil }
+
+// fakeNotificationSession adds RFC 5277 notification support to a mocked ops.OpSession, so tests
+// can drive ON_CHANGE subscriptions by pushing XML onto notifs without a real NETCONF device.
+type fakeNotificationSession struct {
+	ops.OpSession
+	notifs chan string
+}
+
+func (f *fakeNotificationSession) CreateSubscription(filter interface{}, stream string, startTime, stopTime *time.Time) error {
+	return nil
+}
+
+func (f *fakeNotificationSession) Notifications() <-chan string { return f.notifs }
+
+func subscribeRequestFor(t *testing.T, sub *gnmi.SubscriptionList) *gnmi.SubscribeRequest {
+	t.Helper()
+	return &gnmi.SubscribeRequest{Request: &gnmi.SubscribeRequest_Subscribe{Subscribe: sub}}
+}
+
+func maxSessionsPath(t *testing.T) *gnmi.Path {
+	t.Helper()
+	var path gnmi.Path
+	assert.NoError(t, proto.UnmarshalText(`
+		elem: <name: "configuration" >
+		elem: <name: "system" >
+		elem: <name: "services" >
+		elem: <name: "ssh" >
+		elem: <name: "max-sessions-per-connection" >
+	`, &path))
+	return &path
+}
+
+func TestSubscribeOnce(t *testing.T) {
+	mockNc := &mocks.OpSession{}
+	mockNc.On("GetConfigSubtree", mock.Anything, ops.RunningCfg, mock.Anything).Return(
+		func(filter interface{}, source string, result interface{}) error {
+			*result.(*string) = `<configuration><system><services><ssh><max-sessions-per-connection>32</max-sessions-per-connection></ssh></services></system></configuration>`
+			return nil
+		})
+
+	model = NewModel(modeldata.ModelData, gostruct.SchemaTree["Device"])
+	s, err := NewAdapter(model, mockNc)
+	assert.NoError(t, err)
+
+	sub := &gnmi.SubscriptionList{
+		Mode:         gnmi.SubscriptionList_ONCE,
+		Subscription: []*gnmi.Subscription{{Path: maxSessionsPath(t)}},
+	}
+	stream := newFakeSubscribeStream(context.Background())
+	stream.recvCh <- subscribeRequestFor(t, sub)
+	close(stream.recvCh)
+
+	assert.NoError(t, s.Subscribe(stream))
+
+	resp := stream.responses()
+	assert.Len(t, resp, 2, "expected one Update and one SyncResponse")
+	var got int64
+	assert.NoError(t, json.Unmarshal(resp[0].GetUpdate().GetUpdate()[0].GetVal().GetJsonVal(), &got))
+	assert.Equal(t, int64(32), got)
+	assert.True(t, resp[1].GetSyncResponse())
+}
+
+func TestSubscribePoll(t *testing.T) {
+	mockNc := &mocks.OpSession{}
+	mockNc.On("GetConfigSubtree", mock.Anything, ops.RunningCfg, mock.Anything).Return(
+		func(filter interface{}, source string, result interface{}) error {
+			*result.(*string) = `<configuration><system><services><ssh><max-sessions-per-connection>32</max-sessions-per-connection></ssh></services></system></configuration>`
+			return nil
+		})
+
+	model = NewModel(modeldata.ModelData, gostruct.SchemaTree["Device"])
+	s, err := NewAdapter(model, mockNc)
+	assert.NoError(t, err)
+
+	sub := &gnmi.SubscriptionList{
+		Mode:         gnmi.SubscriptionList_POLL,
+		Subscription: []*gnmi.Subscription{{Path: maxSessionsPath(t)}},
+	}
+	stream := newFakeSubscribeStream(context.Background())
+	stream.recvCh <- subscribeRequestFor(t, sub)
+	stream.recvCh <- &gnmi.SubscribeRequest{Request: &gnmi.SubscribeRequest_Poll{Poll: &gnmi.Poll{}}}
+	close(stream.recvCh)
+
+	// The client disconnecting surfaces as io.EOF from Recv, which is how a POLL subscription
+	// normally ends.
+	assert.Equal(t, io.EOF, s.Subscribe(stream))
+
+	resp := stream.responses()
+	assert.Len(t, resp, 4, "expected an Update+SyncResponse pair for the initial walk and for the poll trigger")
+}
+
+func TestSubscribeStreamOnChange(t *testing.T) {
+	mockNc := &mocks.OpSession{}
+	notifs := make(chan string, 1)
+	ns := &fakeNotificationSession{OpSession: mockNc, notifs: notifs}
+
+	model = NewModel(modeldata.ModelData, gostruct.SchemaTree["Device"])
+	s, err := NewAdapter(model, ns)
+	assert.NoError(t, err)
+
+	sub := &gnmi.SubscriptionList{
+		Mode:        gnmi.SubscriptionList_STREAM,
+		UpdatesOnly: true,
+		Subscription: []*gnmi.Subscription{{
+			Path: maxSessionsPath(t),
+			Mode: gnmi.SubscriptionMode_ON_CHANGE,
+		}},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := newFakeSubscribeStream(ctx)
+	stream.recvCh <- subscribeRequestFor(t, sub)
+
+	done := make(chan error, 1)
+	go func() { done <- s.Subscribe(stream) }()
+
+	assert.Eventually(t, func() bool { return len(stream.responses()) == 1 }, time.Second, time.Millisecond,
+		"expected the initial SyncResponse (updates_only suppresses the snapshot walk)")
+
+	notifs <- `<configuration><system><services><ssh><max-sessions-per-connection>64</max-sessions-per-connection></ssh></services></system></configuration>`
+
+	assert.Eventually(t, func() bool { return len(stream.responses()) == 2 }, time.Second, time.Millisecond,
+		"expected the ON_CHANGE notification to be translated into an Update")
+
+	resp := stream.responses()
+	var got int64
+	assert.NoError(t, json.Unmarshal(resp[1].GetUpdate().GetUpdate()[0].GetVal().GetJsonVal(), &got))
+	assert.Equal(t, int64(64), got)
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+}
+
+// TestSubscribeStreamOnChangeViaDeviceRegistry exercises the same ON_CHANGE path as
+// TestSubscribeStreamOnChange, but against an Adapter built with NewAdapterWithDeviceRegistry, the
+// dispatch path cmd actually wires up for a multi-device deployment. It guards against the
+// notificationBridge resolving its NETCONF session by type-asserting the Adapter's bare ncs field
+// (which a DeviceRegistry-backed Adapter never populates) instead of going through resolveTarget.
+func TestSubscribeStreamOnChangeViaDeviceRegistry(t *testing.T) {
+	mockNc := &mocks.OpSession{}
+	notifs := make(chan string, 1)
+	ns := &fakeNotificationSession{OpSession: mockNc, notifs: notifs}
+
+	registry := NewDeviceRegistry(func(ctx context.Context, cfg DeviceConfig) (ops.OpSession, error) {
+		return ns, nil
+	}, 1)
+	registry.reload([]DeviceConfig{{Target: "dev1"}})
+
+	model = NewModel(modeldata.ModelData, gostruct.SchemaTree["Device"])
+	s, err := NewAdapterWithDeviceRegistry(model, registry)
+	assert.NoError(t, err)
+
+	sub := &gnmi.SubscriptionList{
+		Mode:        gnmi.SubscriptionList_STREAM,
+		UpdatesOnly: true,
+		Prefix:      &gnmi.Path{Target: "dev1"},
+		Subscription: []*gnmi.Subscription{{
+			Path: maxSessionsPath(t),
+			Mode: gnmi.SubscriptionMode_ON_CHANGE,
+		}},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := newFakeSubscribeStream(ctx)
+	stream.recvCh <- subscribeRequestFor(t, sub)
+
+	done := make(chan error, 1)
+	go func() { done <- s.Subscribe(stream) }()
+
+	assert.Eventually(t, func() bool { return len(stream.responses()) == 1 }, time.Second, time.Millisecond,
+		"expected the initial SyncResponse (updates_only suppresses the snapshot walk)")
+
+	notifs <- `<configuration><system><services><ssh><max-sessions-per-connection>64</max-sessions-per-connection></ssh></services></system></configuration>`
+
+	assert.Eventually(t, func() bool { return len(stream.responses()) == 2 }, time.Second, time.Millisecond,
+		"expected the ON_CHANGE notification to be translated into an Update, proving ON_CHANGE works when "+
+			"the session is resolved through a DeviceRegistry rather than a bare ncs")
+
+	resp := stream.responses()
+	var got int64
+	assert.NoError(t, json.Unmarshal(resp[1].GetUpdate().GetUpdate()[0].GetVal().GetJsonVal(), &got))
+	assert.Equal(t, int64(64), got)
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+}
@@ -0,0 +1,342 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package adapter implements a gnmi server that adapts to a netconf device.
+package adapter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/pkg/errors"
+	log "k8s.io/klog"
+)
+
+// streamUpdateQueueDepth bounds the number of buffered updates per STREAM subscription before the
+// oldest queued sample is dropped in favour of the newest.
+const streamUpdateQueueDepth = 100
+
+// defaultSampleInterval is used for a SAMPLE (or TARGET_DEFINED) subscription that does not
+// specify a sample_interval.
+const defaultSampleInterval = 10 * time.Second
+
+// notificationSession is implemented by NETCONF sessions capable of RFC 5277 event notifications.
+// ops.OpSession implementations that do not support notifications simply do not satisfy this
+// interface, and ON_CHANGE subscriptions against them are refused.
+type notificationSession interface {
+	CreateSubscription(filter interface{}, stream string, startTime, stopTime *time.Time) error
+	Notifications() <-chan string
+}
+
+// subscription is the handle returned by subscriptionManager.start; stop() uses it to cancel every
+// goroutine started on behalf of one Subscribe STREAM RPC.
+type subscription struct {
+	cancel  context.CancelFunc
+	updates chan *gnmi.SubscribeResponse
+}
+
+// subscriptionManager owns the long-lived per-path goroutines (SAMPLE tickers and ON_CHANGE
+// NETCONF notification bridges) that back STREAM subscriptions.
+type subscriptionManager struct {
+	adapter *Adapter
+
+	mu      sync.Mutex
+	dropped uint64
+	bridges map[string]*notificationBridge // shared ON_CHANGE notification stream per gNMI target, created lazily
+}
+
+// subscriptionManager lazily creates and caches the Adapter's subscription manager.
+func (a *Adapter) subscriptionManager() *subscriptionManager {
+	a.subMgrOnce.Do(func() {
+		a.subMgr = &subscriptionManager{adapter: a, bridges: make(map[string]*notificationBridge)}
+	})
+	return a.subMgr
+}
+
+// Stats reports how many samples have been dropped across all subscriptions because a client's
+// update queue was full.
+func (m *subscriptionManager) Stats() (dropped uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.dropped
+}
+
+// start launches one goroutine per requested path - a SAMPLE ticker or an ON_CHANGE notification
+// bridge - and returns a subscription handle together with the channel updates are delivered on.
+func (m *subscriptionManager) start(ctx context.Context, sub *gnmi.SubscriptionList) *subscription {
+	ctx, cancel := context.WithCancel(ctx)
+	s := &subscription{cancel: cancel, updates: make(chan *gnmi.SubscribeResponse, streamUpdateQueueDepth)}
+
+	for _, p := range sub.GetSubscription() {
+		if p.GetMode() == gnmi.SubscriptionMode_ON_CHANGE {
+			go m.runOnChange(ctx, sub, p, s.updates)
+		} else {
+			// SAMPLE and TARGET_DEFINED both resolve to a ticker-driven poll of processPath;
+			// TARGET_DEFINED defaults to SAMPLE because the adapter has no cheaper native change
+			// signal for an arbitrary NETCONF subtree.
+			go m.runSample(ctx, sub, p, s.updates)
+		}
+	}
+	return s
+}
+
+// stop tears down every goroutine started for the given subscription.
+func (m *subscriptionManager) stop(s *subscription) {
+	s.cancel()
+}
+
+// runSample polls processPath on a ticker derived from the subscription's sample_interval (falling
+// back to defaultSampleInterval when unset), applying suppress_redundant to avoid re-sending an
+// unchanged leaf and heartbeat_interval to force a resend even when suppressed.
+func (m *subscriptionManager) runSample(ctx context.Context, sub *gnmi.SubscriptionList, p *gnmi.Subscription, updates chan *gnmi.SubscribeResponse) {
+	interval := time.Duration(p.GetSampleInterval())
+	if interval <= 0 {
+		interval = defaultSampleInterval
+	}
+	heartbeat := time.Duration(p.GetHeartbeatInterval())
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastSent *gnmi.TypedValue
+	var lastSentAt time.Time
+	getReq := &gnmi.GetRequest{Prefix: sub.GetPrefix(), Encoding: gnmi.Encoding_JSON}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := m.adapter.processPath(ctx, getReq, p.GetPath())
+			if err != nil {
+				log.Warningf("sample subscription for %v failed: %v", p.GetPath(), err)
+				continue
+			}
+			redundant := p.GetSuppressRedundant() && len(n.GetUpdate()) == 1 && sameValue(lastSent, n.GetUpdate()[0].GetVal())
+			if redundant && (heartbeat == 0 || time.Since(lastSentAt) < heartbeat) {
+				continue
+			}
+			m.deliver(updates, &gnmi.SubscribeResponse{Response: &gnmi.SubscribeResponse_Update{Update: n}})
+			if len(n.GetUpdate()) == 1 {
+				lastSent = n.GetUpdate()[0].GetVal()
+			}
+			lastSentAt = time.Now()
+		}
+	}
+}
+
+// runOnChange resolves the notificationBridge for p's target - the same resolveTarget/registry/pool
+// dispatch Get and Set use - registers p against it, and translates every notification the bridge
+// fans out through the same netconfXMLtoMap/buildGnmiNotification pipeline used by Get, filtering
+// for the part of the notification that touches p's path.
+func (m *subscriptionManager) runOnChange(ctx context.Context, sub *gnmi.SubscriptionList, p *gnmi.Subscription, updates chan *gnmi.SubscribeResponse) {
+	target := targetFor(sub.GetPrefix(), p.GetPath())
+	bridge, err := m.notificationBridge(ctx, target)
+	if err != nil {
+		log.Warningf("ON_CHANGE requested for %v but %v", p.GetPath(), err)
+		return
+	}
+
+	entry := getSchemaEntryForPath(bridge.model, p.GetPath())
+	if entry == nil {
+		log.Warningf("ON_CHANGE path %v is not defined in the schema", p.GetPath())
+		return
+	}
+
+	id, notifications := bridge.register()
+	defer bridge.unregister(id)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case xmlNotif, ok := <-notifications:
+			if !ok {
+				return
+			}
+			netconfMap, err := m.adapter.netconfXMLtoMap(ctx, bridge.model, xmlNotif)
+			if err != nil {
+				log.Warningf("failed to decode notification for %v: %v", p.GetPath(), err)
+				continue
+			}
+			requestedValue, err := getRequestedNode(netconfMap, p.GetPath())
+			if err != nil {
+				// The notification did not touch this path; nothing to deliver.
+				continue
+			}
+			n, err := m.adapter.buildGnmiNotification(ctx, entry, requestedValue, p.GetPath(), sub.GetPrefix(), gnmi.Encoding_JSON)
+			if err != nil {
+				log.Warningf("failed to translate notification for %v: %v", p.GetPath(), err)
+				continue
+			}
+			m.deliver(updates, &gnmi.SubscribeResponse{Response: &gnmi.SubscribeResponse_Update{Update: n}})
+		}
+	}
+}
+
+// notificationBridge returns the subscriptionManager's shared notificationBridge for target,
+// resolving its (Model, NETCONF session) pair via resolveTarget - the same registry/pool/resolver
+// dispatch Get and Set use - and creating the bridge (issuing the one create-subscription call it
+// needs) the first time any ON_CHANGE path is registered against that target. Every subsequent
+// ON_CHANGE subscription against the same target, however many distinct paths or clients it covers,
+// reuses the same bridge rather than opening another NETCONF subscription against the device. The
+// session resolved for a bridge is held for the bridge's lifetime rather than returned via
+// releaseTarget, since it backs a standing RFC 5277 subscription rather than a single RPC.
+func (m *subscriptionManager) notificationBridge(ctx context.Context, target string) (*notificationBridge, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if b, ok := m.bridges[target]; ok {
+		return b, nil
+	}
+	mdl, ncs, err := m.adapter.resolveTarget(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	ns, ok := ncs.(notificationSession)
+	if !ok {
+		return nil, errors.New("the NETCONF session does not support notifications")
+	}
+	bridge, err := newNotificationBridge(m, target, ns, mdl)
+	if err != nil {
+		return nil, errors.Wrap(err, "create-subscription failed")
+	}
+	m.bridges[target] = bridge
+	return bridge, nil
+}
+
+// evictBridge removes target's bridge from m.bridges, provided it is still b - a bridge replaced by a
+// newer one for the same target should not have its replacement evicted out from under it. Called by
+// notificationBridge.run when its underlying NETCONF session's notification stream closes, so the
+// next ON_CHANGE subscription against target creates a fresh bridge instead of reusing a dead one.
+func (m *subscriptionManager) evictBridge(target string, b *notificationBridge) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.bridges[target] == b {
+		delete(m.bridges, target)
+	}
+}
+
+// notificationBridge fans out a single RFC 5277 create-subscription event stream to every ON_CHANGE
+// subscription registered against it, so that N subscriptions touching the same NETCONF session -
+// whether from one client watching several paths or several clients watching the same path - cost
+// exactly one NETCONF subscription rather than N. The subscription carries no filter: each listener
+// is responsible for picking its own path back out of the full notification (see runOnChange).
+type notificationBridge struct {
+	ns    notificationSession
+	model *Model
+
+	// mgr and target identify where this bridge is cached in mgr.bridges, so run can evict it once
+	// the underlying NETCONF session disconnects.
+	mgr    *subscriptionManager
+	target string
+
+	mu        sync.Mutex
+	listeners map[int]chan string
+	nextID    int
+}
+
+// newNotificationBridge issues the create-subscription call on ns and starts the goroutine that
+// fans its notifications out to registered listeners; model is used to decode those notifications.
+// mgr and target are recorded so the bridge can remove itself from mgr.bridges when it dies.
+func newNotificationBridge(mgr *subscriptionManager, target string, ns notificationSession, model *Model) (*notificationBridge, error) {
+	if err := ns.CreateSubscription(nil, "", nil, nil); err != nil {
+		return nil, err
+	}
+	b := &notificationBridge{ns: ns, model: model, mgr: mgr, target: target, listeners: make(map[int]chan string)}
+	go b.run()
+	return b, nil
+}
+
+// run copies every notification from the underlying NETCONF session to each registered listener
+// until the session's notification channel closes, then closes every listener in turn and evicts the
+// bridge from mgr.bridges, so the next ON_CHANGE subscription against target creates a fresh bridge
+// (and a fresh NETCONF subscription) instead of being handed back this now-dead one forever.
+func (b *notificationBridge) run() {
+	for xmlNotif := range b.ns.Notifications() {
+		b.mu.Lock()
+		for _, ch := range b.listeners {
+			select {
+			case ch <- xmlNotif:
+			default:
+				// A slow listener drops a notification rather than blocking its siblings; the next
+				// one will still let it re-derive current state for its path.
+			}
+		}
+		b.mu.Unlock()
+	}
+
+	b.mu.Lock()
+	for id, ch := range b.listeners {
+		close(ch)
+		delete(b.listeners, id)
+	}
+	b.mu.Unlock()
+
+	b.mgr.evictBridge(b.target, b)
+}
+
+// register adds a new listener to the bridge, returning its id (for unregister) and the channel
+// notifications will be delivered on.
+func (b *notificationBridge) register() (int, <-chan string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.nextID
+	b.nextID++
+	ch := make(chan string, 1)
+	b.listeners[id] = ch
+	return id, ch
+}
+
+// unregister removes the listener added by register, so runOnChange's goroutine for a torn-down
+// subscription stops being sent notifications.
+func (b *notificationBridge) unregister(id int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.listeners, id)
+}
+
+// deliver enqueues resp on updates, dropping the oldest queued update rather than blocking when the
+// client is not draining the stream fast enough.
+func (m *subscriptionManager) deliver(updates chan *gnmi.SubscribeResponse, resp *gnmi.SubscribeResponse) {
+	select {
+	case updates <- resp:
+		return
+	default:
+	}
+
+	m.mu.Lock()
+	m.dropped++
+	m.mu.Unlock()
+
+	select {
+	case <-updates:
+	default:
+	}
+	select {
+	case updates <- resp:
+	default:
+	}
+}
+
+func sameValue(a, b *gnmi.TypedValue) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return proto.Equal(a, b)
+}
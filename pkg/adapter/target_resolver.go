@@ -0,0 +1,173 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package adapter implements a gnmi server that adapts to a netconf device.
+package adapter
+
+import (
+	"context"
+	"io/ioutil"
+	"sync"
+
+	"github.com/damianoneill/net/v2/netconf/ops"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/yaml.v2"
+)
+
+// DeviceBinding is the (Model, NETCONF session) pair a TargetResolver hands back for a gNMI target.
+// Unlike DeviceRegistry, which shares one Model across every target, a TargetResolver lets each
+// target be served against its own schema - e.g. a mixed fleet of Junos and IOS-XR devices fronted
+// by a single adapter process.
+type DeviceBinding struct {
+	Model   *Model
+	Session ops.OpSession
+}
+
+// TargetResolver maps a gNMI target (gnmi.Path.Target, falling back to gnmi.Prefix.Target) to the
+// DeviceBinding that should serve it, opening NETCONF sessions on demand. Implementations are free to
+// back Lookup with anything from an in-memory map (see StaticTargetResolver) to a remote service
+// registry such as etcd.
+type TargetResolver interface {
+	// Lookup returns the DeviceBinding for target, dialing or leasing a session as needed.
+	Lookup(ctx context.Context, target string) (*DeviceBinding, error)
+	// Release returns a DeviceBinding acquired via Lookup for target. failed should be true when the
+	// caller observed a transport error on binding.Session, so the resolver can discard and redial it
+	// instead of handing it back out.
+	Release(target string, binding *DeviceBinding, failed bool)
+}
+
+// TargetConfig describes a single entry in a StaticTargetResolver inventory file.
+type TargetConfig struct {
+	Target   string `yaml:"target"`
+	Model    string `yaml:"model"`
+	Address  string `yaml:"address"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// TargetDialer opens a NETCONF session for a target described by cfg. It is supplied by the caller
+// of NewStaticTargetResolver so that the adapter package stays independent of the transport used to
+// reach a device's NETCONF endpoint.
+type TargetDialer func(ctx context.Context, cfg TargetConfig) (ops.OpSession, error)
+
+// target is a TargetConfig plus the Model named by it and a session pool dialed lazily on first use.
+type target struct {
+	cfg   TargetConfig
+	model *Model
+	pool  *sessionPool
+}
+
+// StaticTargetResolver is a TargetResolver backed by a fixed, YAML-configured set of targets, each
+// bound to a named entry in a ModelRegistry and dialed through a bounded, idle-evicting sessionPool
+// (see newSessionPool). It implements TargetResolver.
+type StaticTargetResolver struct {
+	models      *ModelRegistry
+	dial        TargetDialer
+	maxSessions int
+
+	mu      sync.RWMutex
+	targets map[string]*target
+}
+
+// NewStaticTargetResolver builds a StaticTargetResolver that selects each target's Model by name from
+// models, dials sessions with dial, and keeps at most maxSessions concurrently-dialed sessions open
+// per target.
+func NewStaticTargetResolver(models *ModelRegistry, dial TargetDialer, maxSessions int) *StaticTargetResolver {
+	return &StaticTargetResolver{models: models, dial: dial, maxSessions: maxSessions, targets: make(map[string]*target)}
+}
+
+// LoadInventoryFile replaces the resolver's target set with the contents of the YAML inventory file
+// at path. Targets that are unchanged keep their existing (and possibly already-dialed) session
+// pool; targets that are removed have their pool's sessions closed.
+func (r *StaticTargetResolver) LoadInventoryFile(path string) error {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read target inventory %s", path)
+	}
+	var cfgs []TargetConfig
+	if err := yaml.Unmarshal(raw, &cfgs); err != nil {
+		return errors.Wrapf(err, "failed to parse target inventory %s", path)
+	}
+	return r.reload(cfgs)
+}
+
+func (r *StaticTargetResolver) reload(cfgs []TargetConfig) error {
+	next := make(map[string]*target, len(cfgs))
+
+	r.mu.Lock()
+	for _, cfg := range cfgs {
+		if existing, ok := r.targets[cfg.Target]; ok && existing.cfg == cfg {
+			next[cfg.Target] = existing
+			continue
+		}
+		m, ok := r.models.byName(cfg.Model)
+		if !ok {
+			r.mu.Unlock()
+			return errors.Errorf("target %q refers to unknown model %q", cfg.Target, cfg.Model)
+		}
+		t := &target{cfg: cfg, model: m}
+		t.pool = newSessionPool(func(ctx context.Context) (ops.OpSession, error) {
+			return r.dial(ctx, t.cfg)
+		}, r.maxSessions)
+		next[cfg.Target] = t
+	}
+	removed := make([]*target, 0, len(r.targets))
+	for name, t := range r.targets {
+		if next[name] != t {
+			removed = append(removed, t)
+		}
+	}
+	r.targets = next
+	r.mu.Unlock()
+
+	for _, t := range removed {
+		t.pool.Close()
+	}
+	return nil
+}
+
+// Lookup implements TargetResolver.
+func (r *StaticTargetResolver) Lookup(ctx context.Context, target string) (*DeviceBinding, error) {
+	t, ok := r.lookup(target)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown gNMI target %q", target)
+	}
+	session, err := t.pool.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &DeviceBinding{Model: t.model, Session: session}, nil
+}
+
+// Release implements TargetResolver.
+func (r *StaticTargetResolver) Release(name string, binding *DeviceBinding, failed bool) {
+	t, ok := r.lookup(name)
+	if !ok {
+		return
+	}
+	if failed {
+		t.pool.Discard(binding.Session)
+	} else {
+		t.pool.Put(binding.Session)
+	}
+}
+
+func (r *StaticTargetResolver) lookup(name string) (*target, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.targets[name]
+	return t, ok
+}
@@ -0,0 +1,119 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package adapter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/damianoneill/net/v2/netconf/ops"
+	"github.com/damianoneill/net/v2/netconf/ops/mocks"
+	"github.com/stretchr/testify/mock"
+	assert "github.com/stretchr/testify/require"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeTargetResolver is a TargetResolver over a fixed set of DeviceBindings, for tests that need Get
+// routed to a specific fake (Model, NETCONF session) pair by target.
+type fakeTargetResolver struct {
+	bindings map[string]*DeviceBinding
+}
+
+func (r *fakeTargetResolver) Lookup(ctx context.Context, target string) (*DeviceBinding, error) {
+	b, ok := r.bindings[target]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown gNMI target %q", target)
+	}
+	return b, nil
+}
+
+func (r *fakeTargetResolver) Release(target string, binding *DeviceBinding, failed bool) {}
+
+func TestGetRoutesByTargetAcrossDevicesWithIsolatedResults(t *testing.T) {
+	device1 := &mocks.OpSession{}
+	device1.On("GetConfigSubtree", `<configuration><version></version></configuration>`, ops.RunningCfg, mock.Anything).Return(
+		func(filter interface{}, source string, result interface{}) error {
+			*result.(*string) = `<configuration><version>device1-version</version></configuration>`
+			return nil
+		})
+	device2 := &mocks.OpSession{}
+	device2.On("GetConfigSubtree", `<configuration><version></version></configuration>`, ops.RunningCfg, mock.Anything).Return(
+		func(filter interface{}, source string, result interface{}) error {
+			*result.(*string) = `<configuration><version>device2-version</version></configuration>`
+			return nil
+		})
+
+	resolver := &fakeTargetResolver{bindings: map[string]*DeviceBinding{
+		"device1": {Model: model, Session: device1},
+		"device2": {Model: model, Session: device2},
+	}}
+	s, err := NewAdapterWithTargetResolver(resolver)
+	assert.NoError(t, err)
+
+	var path gnmi.Path
+	assert.NoError(t, proto.UnmarshalText(`elem: <name: "version" >`, &path))
+
+	for target, want := range map[string]string{"device1": "device1-version", "device2": "device2-version"} {
+		req := &gnmi.GetRequest{
+			Path:   []*gnmi.Path{&path},
+			Prefix: &gnmi.Path{Target: target, Elem: []*gnmi.PathElem{{Name: "configuration"}}},
+		}
+		resp, err := s.Get(context.TODO(), req)
+		assert.NoError(t, err, "target %s", target)
+		assert.Equal(t, want, resp.GetNotification()[0].GetUpdate()[0].GetVal().GetStringVal(), "target %s", target)
+	}
+}
+
+func TestGetUnknownTargetIsNotFound(t *testing.T) {
+	resolver := &fakeTargetResolver{bindings: map[string]*DeviceBinding{"device1": {Model: model, Session: &mocks.OpSession{}}}}
+	s, err := NewAdapterWithTargetResolver(resolver)
+	assert.NoError(t, err)
+
+	var path gnmi.Path
+	assert.NoError(t, proto.UnmarshalText(`elem: <name: "version" >`, &path))
+	req := &gnmi.GetRequest{
+		Path:   []*gnmi.Path{&path},
+		Prefix: &gnmi.Path{Target: "unknown-device", Elem: []*gnmi.PathElem{{Name: "configuration"}}},
+	}
+
+	_, err = s.Get(context.TODO(), req)
+	assert.Error(t, err)
+}
+
+// BenchmarkStaticTargetResolverSessionChurn opens and closes 100 sessions against a single target's
+// pool to validate that the pool redials correctly under repeated Get/Discard churn.
+func BenchmarkStaticTargetResolverSessionChurn(b *testing.B) {
+	registry := NewModelRegistry()
+	registry.Register("test-module", "onf", "1.0", model.schemaTreeRoot)
+
+	dialed := 0
+	resolver := NewStaticTargetResolver(registry, func(ctx context.Context, cfg TargetConfig) (ops.OpSession, error) {
+		dialed++
+		return &mocks.OpSession{}, nil
+	}, 1)
+	assert.NoError(b, resolver.reload([]TargetConfig{{Target: "device1", Model: "test-module"}}))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 100; j++ {
+			binding, err := resolver.Lookup(context.Background(), "device1")
+			assert.NoError(b, err)
+			resolver.Release("device1", binding, true)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package telemetry configures the OpenTelemetry tracer/meter providers used to instrument the
+// gNMI<->NETCONF pipeline, and exposes the attribute keys common to the adapter's spans and metrics.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/api/global"
+	"go.opentelemetry.io/otel/api/metric"
+	"go.opentelemetry.io/otel/api/trace"
+	"go.opentelemetry.io/otel/exporters/otlp"
+	"go.opentelemetry.io/otel/exporters/trace/jaeger"
+	"go.opentelemetry.io/otel/label"
+	export "go.opentelemetry.io/otel/sdk/export/trace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Exporter selects which backend span/metric data is sent to.
+type Exporter string
+
+// Supported exporters.
+const (
+	ExporterNone     Exporter = "none"
+	ExporterOTLPHTTP Exporter = "otlp-http"
+	ExporterOTLPGRPC Exporter = "otlp-grpc"
+	ExporterJaeger   Exporter = "jaeger"
+)
+
+// Config selects the exporter, endpoint and trace sampling ratio used by Init.
+type Config struct {
+	Exporter    Exporter
+	Endpoint    string
+	ServiceName string
+	// SampleRatio is the fraction, in [0,1], of traces without a sampled parent that are recorded;
+	// 0 defaults to always-on (1.0).
+	SampleRatio float64
+}
+
+// Attribute keys shared by every span the adapter opens.
+const (
+	KeyGNMIPath         = label.Key("gnmi.path")
+	KeyNetconfDatastore = label.Key("netconf.datastore")
+	KeyNetconfOperation = label.Key("netconf.operation")
+	KeyDeviceTarget     = label.Key("device.target")
+	KeyResponseBytes    = label.Key("response.bytes")
+)
+
+var (
+	tracer = global.Tracer("gnmi-netconf-adapter")
+	meter  = global.Meter("gnmi-netconf-adapter")
+
+	editConfigLatency = metric.Must(meter).NewFloat64ValueRecorder(
+		"netconf.edit_config.latency_ms",
+		metric.WithDescription("Latency of NETCONF edit-config calls issued on behalf of a gNMI Set, in milliseconds"),
+	)
+	operationFailures = metric.Must(meter).NewInt64Counter(
+		"netconf.operation.failures",
+		metric.WithDescription("Count of failed NETCONF operations issued on behalf of a gNMI Set, by operation"),
+	)
+)
+
+// Tracer returns the adapter's shared tracer. Until Init is called it delivers no-op spans.
+func Tracer() trace.Tracer { return tracer }
+
+// Meter returns the adapter's shared meter. Until Init is called it delivers no-op instruments.
+func Meter() metric.Meter { return meter }
+
+// RecordEditConfigLatency records how long a netconf edit-config call against datastore took for
+// the given gNMI operation ("delete", "replace" or "merge", see mapOperation).
+func RecordEditConfigLatency(ctx context.Context, datastore, operation string, d time.Duration) {
+	editConfigLatency.Record(ctx, float64(d.Milliseconds()), KeyNetconfDatastore.String(datastore), KeyNetconfOperation.String(operation))
+}
+
+// RecordOperationFailure increments the failure count for a NETCONF operation issued on behalf of a
+// gNMI Set.
+func RecordOperationFailure(ctx context.Context, operation string) {
+	operationFailures.Add(ctx, 1, KeyNetconfOperation.String(operation))
+}
+
+// Init installs a global trace provider built from cfg. Passing ExporterNone (the zero value)
+// leaves the existing no-op global provider in place, so instrumentation is free when telemetry is
+// not configured.
+func Init(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	if cfg.Exporter == "" || cfg.Exporter == ExporterNone {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	res := resource.New(label.String("service.name", cfg.ServiceName))
+
+	var sp export.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case ExporterOTLPHTTP:
+		// The otlp exporter pinned in go.mod only ever dials out over gRPC; there is no HTTP
+		// transport to select at this version.
+		return nil, fmt.Errorf("otlp-http exporter is not available at the pinned otlp exporter version; use otlp-grpc instead")
+	case ExporterOTLPGRPC:
+		sp, err = otlp.NewExporter(otlp.WithAddress(cfg.Endpoint), otlp.WithInsecure())
+	case ExporterJaeger:
+		sp, err = jaeger.NewRawExporter(jaeger.WithCollectorEndpoint(cfg.Endpoint))
+	default:
+		return nil, fmt.Errorf("unsupported otel exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to build %s exporter: %w", cfg.Exporter, err)
+	}
+
+	ratio := cfg.SampleRatio
+	if ratio <= 0 {
+		ratio = 1
+	}
+
+	bsp := sdktrace.NewBatchSpanProcessor(sp)
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(bsp),
+		sdktrace.WithResource(res),
+		sdktrace.WithConfig(sdktrace.Config{DefaultSampler: sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))}),
+	)
+	global.SetTracerProvider(provider)
+	tracer = global.Tracer("gnmi-netconf-adapter")
+
+	return func(ctx context.Context) error {
+		bsp.Shutdown()
+		return sp.Shutdown(ctx)
+	}, nil
+}
+
+// SpanRecorder is a sdktrace.SpanProcessor that keeps every span it sees once it ends, so tests can
+// assert on span names, attributes and parent/child relationships without wiring up a real exporter.
+type SpanRecorder struct {
+	mu    sync.Mutex
+	spans []*export.SpanData
+}
+
+// OnStart implements sdktrace.SpanProcessor.
+func (r *SpanRecorder) OnStart(s *export.SpanData) {}
+
+// OnEnd implements sdktrace.SpanProcessor.
+func (r *SpanRecorder) OnEnd(s *export.SpanData) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, s)
+}
+
+// Shutdown implements sdktrace.SpanProcessor.
+func (r *SpanRecorder) Shutdown() {}
+
+// ForceFlush implements sdktrace.SpanProcessor.
+func (r *SpanRecorder) ForceFlush() {}
+
+// Spans returns a snapshot of every span recorded so far, in the order they ended.
+func (r *SpanRecorder) Spans() []*export.SpanData {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]*export.SpanData, len(r.spans))
+	copy(out, r.spans)
+	return out
+}
+
+// InstallTestRecorder installs a SpanRecorder as the global trace provider's only processor, always
+// sampling, and returns it along with a func that restores the previous global provider. Intended
+// for use by tests that need to assert on the spans the adapter opens.
+func InstallTestRecorder() (*SpanRecorder, func()) {
+	rec := &SpanRecorder{}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(rec),
+		sdktrace.WithConfig(sdktrace.Config{DefaultSampler: sdktrace.AlwaysSample()}),
+	)
+	prevProvider := global.TracerProvider()
+	prevTracer := tracer
+	global.SetTracerProvider(provider)
+	tracer = global.Tracer("gnmi-netconf-adapter")
+
+	return rec, func() {
+		global.SetTracerProvider(prevProvider)
+		tracer = prevTracer
+	}
+}